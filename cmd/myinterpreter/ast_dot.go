@@ -0,0 +1,152 @@
+// ast_dot.go
+package main
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/codecrafters-io/interpreter-starter-go/lox"
+)
+
+// dotWriter renders a syntax tree as a Graphviz digraph
+type dotWriter struct {
+	w      io.Writer
+	nextID int
+}
+
+// WriteASTDot writes the statements as a Graphviz digraph rooted at a
+// "program" node. Every node is labelled with its kind, operator or value and
+// its source line; edges are labelled with the child field name.
+func WriteASTDot(w io.Writer, statements []lox.Stmt) {
+	d := &dotWriter{w: w}
+
+	fmt.Fprintln(w, "digraph AST {")
+	fmt.Fprintln(w, "  node [shape=box, fontname=\"monospace\"];")
+	root := d.node("program", "ellipse")
+	for i, stmt := range statements {
+		d.edge(root, d.write(stmt), fmt.Sprint(i))
+	}
+	fmt.Fprintln(w, "}")
+}
+
+// write emits a node and its children, returning the node id
+func (d *dotWriter) write(node lox.Expr) string {
+	switch n := node.(type) {
+	case *lox.Literal:
+		value := n.String()
+		if n.Type == "string" {
+			value = fmt.Sprintf("\"%s\"", n.Value) // Escaped once, by node
+		}
+		return d.node(fmt.Sprintf("Literal %s\nline %d", value, n.Line), "box")
+	case *lox.Identifier:
+		return d.node(fmt.Sprintf("Identifier %s\nline %d", n.Name, n.Line), "box")
+	case *lox.Grouping:
+		id := d.node(fmt.Sprintf("Grouping\nline %d", n.Line), "box")
+		d.edge(id, d.write(n.Expression), "expression")
+		return id
+	case *lox.Unary:
+		id := d.node(fmt.Sprintf("Unary %s\nline %d", n.Operator.Lexeme, n.Line), "box")
+		d.edge(id, d.write(n.Right), "right")
+		return id
+	case *lox.Binary:
+		id := d.node(fmt.Sprintf("Binary %s\nline %d", n.Operator.Lexeme, n.Line), "box")
+		d.edge(id, d.write(n.Left), "left")
+		d.edge(id, d.write(n.Right), "right")
+		return id
+	case *lox.AssignStmt:
+		id := d.node(fmt.Sprintf("AssignStmt %s\nline %d", n.Name, n.Line), "box")
+		d.edge(id, d.write(n.Value), "value")
+		return id
+	case *lox.Call:
+		id := d.node(fmt.Sprintf("Call\nline %d", n.Line), "box")
+		d.edge(id, d.write(n.Callee), "callee")
+		for i, argument := range n.Arguments {
+			d.edge(id, d.write(argument), fmt.Sprint(i))
+		}
+		return id
+	case *lox.Get:
+		id := d.node(fmt.Sprintf("Get %s\nline %d", n.Name, n.Line), "box")
+		d.edge(id, d.write(n.Object), "object")
+		return id
+	case *lox.Set:
+		id := d.node(fmt.Sprintf("Set %s\nline %d", n.Name, n.Line), "box")
+		d.edge(id, d.write(n.Object), "object")
+		d.edge(id, d.write(n.Value), "value")
+		return id
+	case *lox.ListLiteral:
+		id := d.node(fmt.Sprintf("ListLiteral\nline %d", n.Line), "box")
+		for i, element := range n.Elements {
+			d.edge(id, d.write(element), fmt.Sprint(i))
+		}
+		return id
+	case *lox.MapLiteral:
+		id := d.node(fmt.Sprintf("MapLiteral\nline %d", n.Line), "box")
+		for i := range n.Keys {
+			d.edge(id, d.write(n.Keys[i]), fmt.Sprintf("key %d", i))
+			d.edge(id, d.write(n.Values[i]), fmt.Sprintf("value %d", i))
+		}
+		return id
+	case *lox.Index:
+		id := d.node(fmt.Sprintf("Index\nline %d", n.Line), "box")
+		d.edge(id, d.write(n.Object), "object")
+		d.edge(id, d.write(n.Index), "index")
+		return id
+	case *lox.SetIndex:
+		id := d.node(fmt.Sprintf("SetIndex\nline %d", n.Line), "box")
+		d.edge(id, d.write(n.Object), "object")
+		d.edge(id, d.write(n.Index), "index")
+		d.edge(id, d.write(n.Value), "value")
+		return id
+	case *lox.ExpressionStatement:
+		id := d.node(fmt.Sprintf("ExpressionStatement\nline %d", n.Line), "box")
+		d.edge(id, d.write(n.Expression), "expression")
+		return id
+	case *lox.PrintStatement:
+		id := d.node(fmt.Sprintf("PrintStatement\nline %d", n.Line), "box")
+		d.edge(id, d.write(n.Expression), "expression")
+		return id
+	case *lox.VarStmt:
+		kind := "VarStmt var"
+		if !n.VarUsed {
+			kind = "VarStmt"
+		}
+		id := d.node(fmt.Sprintf("%s %s\nline %d", kind, n.Name, n.Line), "box")
+		if n.Initializer != nil {
+			d.edge(id, d.write(n.Initializer), "initializer")
+		}
+		return id
+	case *lox.ImportStmt:
+		label := fmt.Sprintf("ImportStmt \"%s\"", n.Path)
+		if n.Name != "" {
+			label = fmt.Sprintf("ImportStmt %s from \"%s\"", n.Name, n.Path)
+		}
+		return d.node(fmt.Sprintf("%s\nline %d", label, n.Line), "box")
+	case *lox.BlockStmt:
+		id := d.node(fmt.Sprintf("BlockStmt\nlines %d-%d", n.Line, n.EndLine), "box")
+		for i, stmt := range n.Statements {
+			d.edge(id, d.write(stmt), fmt.Sprint(i))
+		}
+		return id
+	}
+	return d.node(fmt.Sprintf("%T", node), "box")
+}
+
+// node declares a new graph node and returns its id
+func (d *dotWriter) node(label, shape string) string {
+	id := fmt.Sprintf("n%d", d.nextID)
+	d.nextID++
+	fmt.Fprintf(d.w, "  %s [label=\"%s\", shape=%s];\n", id, dotEscape(label), shape)
+	return id
+}
+
+func (d *dotWriter) edge(from, to, label string) {
+	fmt.Fprintf(d.w, "  %s -> %s [label=\"%s\"];\n", from, to, dotEscape(label))
+}
+
+// dotEscape escapes a label for use inside a quoted DOT string
+func dotEscape(label string) string {
+	label = strings.ReplaceAll(label, "\\", "\\\\")
+	label = strings.ReplaceAll(label, "\"", "\\\"")
+	return strings.ReplaceAll(label, "\n", "\\n")
+}
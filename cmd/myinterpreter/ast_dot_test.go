@@ -0,0 +1,31 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/codecrafters-io/interpreter-starter-go/lox"
+)
+
+func TestDotLabelsAreEscapedOnce(t *testing.T) {
+	lexer := lox.NewLexer(`"a" + "b\c"`, false)
+	lexer.SetOutput(io.Discard, io.Discard)
+	lexer.Scan()
+	statements, err := lox.NewParser(lexer, "parse").Parse()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var out strings.Builder
+	WriteASTDot(&out, statements)
+
+	// Lox strings have no escapes, so the second literal holds a backslash
+	for _, want := range []string{
+		`[label="Literal \"a\"\nline 1", shape=box]`,
+		`[label="Literal \"b\\c\"\nline 1", shape=box]`,
+	} {
+		if !strings.Contains(out.String(), want) {
+			t.Errorf("no %s in\n%s", want, out.String())
+		}
+	}
+}
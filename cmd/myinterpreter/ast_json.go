@@ -0,0 +1,276 @@
+// ast_json.go
+package main
+
+/*
+JSON export of the syntax tree, used by `parse --format=json`.
+
+The document is an object with a schema version and the list of parsed
+statements:
+
+	{"version": 1, "statements": [<node>, ...]}
+
+Every node is an object whose first two keys are always "type" (the Go node
+name) and "line". The remaining keys depend on the type:
+
+	Literal             literalType ("number", "string", "boolean", "nil"), value
+	Identifier          name
+	Grouping            expression
+	Unary               operator, right
+	Binary              left, operator, right
+	AssignStmt          name, value
+	ExpressionStatement expression
+	PrintStatement      expression
+	VarStmt             name, declaration, initializer
+	BlockStmt           endLine, statements
+	Call                callee, arguments
+	Get                 object, name
+	Set                 object, name, value
+	ImportStmt          path, name (null for `import "path";`)
+	ListLiteral         elements
+	MapLiteral          entries, a list of {"key": <node>, "value": <node>}
+	Index               object, index
+	SetIndex            object, index, value
+
+"operator" is a token object: {"type": "PLUS", "lexeme": "+", "line": 1}.
+Number literals are written as JSON numbers. "declaration" is true for
+`var x = ...;` and false for a bare `x = ...;` statement. A missing
+initializer is written as null.
+*/
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+
+	"github.com/codecrafters-io/interpreter-starter-go/lox"
+)
+
+// astJSONVersion is bumped whenever the schema above changes incompatibly
+const astJSONVersion = 1
+
+// jsonField is a single key of a jsonObject
+type jsonField struct {
+	Key   string
+	Value interface{}
+}
+
+// jsonObject is a JSON object that keeps its keys in insertion order
+type jsonObject []jsonField
+
+func (o jsonObject) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, field := range o {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		key, err := json.Marshal(field.Key)
+		if err != nil {
+			return nil, err
+		}
+		value, err := json.Marshal(field.Value)
+		if err != nil {
+			return nil, err
+		}
+		buf.Write(key)
+		buf.WriteByte(':')
+		buf.Write(value)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// WriteASTJSON writes the statements as an indented JSON document
+func WriteASTJSON(w io.Writer, statements []lox.Stmt) error {
+	nodes := []interface{}{}
+	for _, stmt := range statements {
+		nodes = append(nodes, nodeToJSON(stmt))
+	}
+	document := jsonObject{
+		{"version", astJSONVersion},
+		{"statements", nodes},
+	}
+
+	encoded, err := json.MarshalIndent(document, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", encoded)
+	return err
+}
+
+// nodeToJSON converts a single node and its children
+func nodeToJSON(node lox.Expr) interface{} {
+	if node == nil {
+		return nil
+	}
+
+	switch n := node.(type) {
+	case *lox.Literal:
+		return jsonObject{
+			{"type", "Literal"},
+			{"line", n.Line},
+			{"literalType", n.Type},
+			{"value", literalJSONValue(n)},
+		}
+	case *lox.Identifier:
+		return jsonObject{
+			{"type", "Identifier"},
+			{"line", n.Line},
+			{"name", n.Name},
+		}
+	case *lox.Grouping:
+		return jsonObject{
+			{"type", "Grouping"},
+			{"line", n.Line},
+			{"expression", nodeToJSON(n.Expression)},
+		}
+	case *lox.Unary:
+		return jsonObject{
+			{"type", "Unary"},
+			{"line", n.Line},
+			{"operator", tokenToJSON(n.Operator)},
+			{"right", nodeToJSON(n.Right)},
+		}
+	case *lox.Binary:
+		return jsonObject{
+			{"type", "Binary"},
+			{"line", n.Line},
+			{"left", nodeToJSON(n.Left)},
+			{"operator", tokenToJSON(n.Operator)},
+			{"right", nodeToJSON(n.Right)},
+		}
+	case *lox.AssignStmt:
+		return jsonObject{
+			{"type", "AssignStmt"},
+			{"line", n.Line},
+			{"name", n.Name},
+			{"value", nodeToJSON(n.Value)},
+		}
+	case *lox.Call:
+		arguments := []interface{}{}
+		for _, argument := range n.Arguments {
+			arguments = append(arguments, nodeToJSON(argument))
+		}
+		return jsonObject{
+			{"type", "Call"},
+			{"line", n.Line},
+			{"callee", nodeToJSON(n.Callee)},
+			{"arguments", arguments},
+		}
+	case *lox.Get:
+		return jsonObject{
+			{"type", "Get"},
+			{"line", n.Line},
+			{"object", nodeToJSON(n.Object)},
+			{"name", n.Name},
+		}
+	case *lox.Set:
+		return jsonObject{
+			{"type", "Set"},
+			{"line", n.Line},
+			{"object", nodeToJSON(n.Object)},
+			{"name", n.Name},
+			{"value", nodeToJSON(n.Value)},
+		}
+	case *lox.ListLiteral:
+		elements := []interface{}{}
+		for _, element := range n.Elements {
+			elements = append(elements, nodeToJSON(element))
+		}
+		return jsonObject{
+			{"type", "ListLiteral"},
+			{"line", n.Line},
+			{"elements", elements},
+		}
+	case *lox.MapLiteral:
+		entries := []interface{}{}
+		for i := range n.Keys {
+			entries = append(entries, jsonObject{{"key", nodeToJSON(n.Keys[i])}, {"value", nodeToJSON(n.Values[i])}})
+		}
+		return jsonObject{
+			{"type", "MapLiteral"},
+			{"line", n.Line},
+			{"entries", entries},
+		}
+	case *lox.Index:
+		return jsonObject{
+			{"type", "Index"},
+			{"line", n.Line},
+			{"object", nodeToJSON(n.Object)},
+			{"index", nodeToJSON(n.Index)},
+		}
+	case *lox.SetIndex:
+		return jsonObject{
+			{"type", "SetIndex"},
+			{"line", n.Line},
+			{"object", nodeToJSON(n.Object)},
+			{"index", nodeToJSON(n.Index)},
+			{"value", nodeToJSON(n.Value)},
+		}
+	case *lox.ExpressionStatement:
+		return jsonObject{
+			{"type", "ExpressionStatement"},
+			{"line", n.Line},
+			{"expression", nodeToJSON(n.Expression)},
+		}
+	case *lox.PrintStatement:
+		return jsonObject{
+			{"type", "PrintStatement"},
+			{"line", n.Line},
+			{"expression", nodeToJSON(n.Expression)},
+		}
+	case *lox.VarStmt:
+		return jsonObject{
+			{"type", "VarStmt"},
+			{"line", n.Line},
+			{"name", n.Name},
+			{"declaration", n.VarUsed},
+			{"initializer", nodeToJSON(n.Initializer)},
+		}
+	case *lox.ImportStmt:
+		var name interface{}
+		if n.Name != "" {
+			name = n.Name
+		}
+		return jsonObject{
+			{"type", "ImportStmt"},
+			{"line", n.Line},
+			{"path", n.Path},
+			{"name", name},
+		}
+	case *lox.BlockStmt:
+		statements := []interface{}{}
+		for _, stmt := range n.Statements {
+			statements = append(statements, nodeToJSON(stmt))
+		}
+		return jsonObject{
+			{"type", "BlockStmt"},
+			{"line", n.Line},
+			{"endLine", n.EndLine},
+			{"statements", statements},
+		}
+	}
+
+	return jsonObject{{"type", fmt.Sprintf("%T", node)}}
+}
+
+// tokenToJSON converts a token such as a binary operator
+func tokenToJSON(token lox.Token) jsonObject {
+	return jsonObject{
+		{"type", token.Type},
+		{"lexeme", token.Lexeme},
+		{"line", token.Line},
+	}
+}
+
+// literalJSONValue returns the literal value with its natural JSON type
+func literalJSONValue(l *lox.Literal) interface{} {
+	if l.Type == "number" {
+		if value, err := lox.ConvertStringToFloat(fmt.Sprint(l.Value), l.Line); err == nil {
+			return value
+		}
+	}
+	return l.Value
+}
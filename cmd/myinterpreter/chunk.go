@@ -0,0 +1,113 @@
+// chunk.go
+package main
+
+// OpCode is a single bytecode instruction, following the clox instruction set
+type OpCode byte
+
+const (
+	OP_CONSTANT OpCode = iota
+	OP_NIL
+	OP_TRUE
+	OP_FALSE
+	OP_POP
+	OP_GET_LOCAL
+	OP_SET_LOCAL
+	OP_GET_GLOBAL
+	OP_DEFINE_GLOBAL
+	OP_SET_GLOBAL
+	OP_GET_PROPERTY
+	OP_SET_PROPERTY
+	OP_EQUAL
+	OP_GREATER
+	OP_LESS
+	OP_ADD
+	OP_SUBTRACT
+	OP_MULTIPLY
+	OP_DIVIDE
+	OP_NOT
+	OP_NEGATE
+	OP_PRINT
+	OP_CALL
+	OP_RETURN
+	OP_BUILD_LIST   // pops as many elements as its operand and pushes a list of them
+	OP_BUILD_MAP    // pops as many key and value pairs as its operand and pushes a map of them
+	OP_GET_INDEX    // pops an index and a list or map, pushes the element
+	OP_SET_INDEX    // pops a value, an index and a list or map, stores and pushes the value
+	OP_IMPORT       // loads the module at a constant path and pushes it
+	OP_IMPORT_NAMES // pops a module and defines its names as globals
+)
+
+var opCodeNames = map[OpCode]string{
+	OP_CONSTANT:      "OP_CONSTANT",
+	OP_NIL:           "OP_NIL",
+	OP_TRUE:          "OP_TRUE",
+	OP_FALSE:         "OP_FALSE",
+	OP_POP:           "OP_POP",
+	OP_GET_LOCAL:     "OP_GET_LOCAL",
+	OP_SET_LOCAL:     "OP_SET_LOCAL",
+	OP_GET_GLOBAL:    "OP_GET_GLOBAL",
+	OP_DEFINE_GLOBAL: "OP_DEFINE_GLOBAL",
+	OP_SET_GLOBAL:    "OP_SET_GLOBAL",
+	OP_GET_PROPERTY:  "OP_GET_PROPERTY",
+	OP_SET_PROPERTY:  "OP_SET_PROPERTY",
+	OP_EQUAL:         "OP_EQUAL",
+	OP_GREATER:       "OP_GREATER",
+	OP_LESS:          "OP_LESS",
+	OP_ADD:           "OP_ADD",
+	OP_SUBTRACT:      "OP_SUBTRACT",
+	OP_MULTIPLY:      "OP_MULTIPLY",
+	OP_DIVIDE:        "OP_DIVIDE",
+	OP_NOT:           "OP_NOT",
+	OP_NEGATE:        "OP_NEGATE",
+	OP_PRINT:         "OP_PRINT",
+	OP_CALL:          "OP_CALL",
+	OP_RETURN:        "OP_RETURN",
+	OP_BUILD_LIST:    "OP_BUILD_LIST",
+	OP_BUILD_MAP:     "OP_BUILD_MAP",
+	OP_GET_INDEX:     "OP_GET_INDEX",
+	OP_SET_INDEX:     "OP_SET_INDEX",
+	OP_IMPORT:        "OP_IMPORT",
+	OP_IMPORT_NAMES:  "OP_IMPORT_NAMES",
+}
+
+func (op OpCode) String() string {
+	if name, ok := opCodeNames[op]; ok {
+		return name
+	}
+	return "OP_UNKNOWN"
+}
+
+// Chunk holds compiled bytecode together with its constant pool.
+// Lines runs parallel to Code so every byte knows its source line.
+type Chunk struct {
+	Code      []byte
+	Lines     []int
+	Constants []interface{}
+}
+
+// Write appends a single byte to the chunk
+func (c *Chunk) Write(b byte, line int) {
+	c.Code = append(c.Code, b)
+	c.Lines = append(c.Lines, line)
+}
+
+// AddConstant stores a value in the constant pool and returns its index
+func (c *Chunk) AddConstant(value interface{}) int {
+	c.Constants = append(c.Constants, value)
+	return len(c.Constants) - 1
+}
+
+// CompiledFunction is a unit of compiled code. The top-level script is a
+// function without a name.
+type CompiledFunction struct {
+	Name  string
+	Arity int
+	Chunk *Chunk
+}
+
+func (f *CompiledFunction) String() string {
+	if f.Name == "" {
+		return "<script>"
+	}
+	return "<fn " + f.Name + ">"
+}
@@ -0,0 +1,397 @@
+// compiler.go
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/codecrafters-io/interpreter-starter-go/lox"
+)
+
+// local is a variable living in a stack slot of the current function
+type local struct {
+	name   string
+	depth  int
+	hidden bool // slot taken ahead of the assignment that defines it
+}
+
+// Compiler turns parsed statements into bytecode, clox style. Top-level
+// variables become globals, variables declared inside blocks become locals.
+// As in the evaluator, an assignment defines the variable in the current
+// scope rather than updating the one it shadows.
+type Compiler struct {
+	function   *CompiledFunction
+	locals     []local
+	scopeDepth int
+	line       int
+}
+
+// NewCompiler creates a compiler for the top-level script
+func NewCompiler() *Compiler {
+	return &Compiler{
+		function: &CompiledFunction{Chunk: &Chunk{}},
+		locals:   []local{{name: "", depth: 0}}, // slot 0 holds the function, as in clox
+		line:     1,
+	}
+}
+
+// Compile compiles a whole program and returns the script function
+func (c *Compiler) Compile(statements []lox.Stmt) *CompiledFunction {
+	for _, stmt := range statements {
+		c.compileNode(stmt)
+	}
+	c.emit(OP_NIL)
+	c.emit(OP_RETURN)
+	return c.function
+}
+
+// compileNode emits the code for a single statement or expression
+func (c *Compiler) compileNode(node lox.Expr) {
+	switch n := node.(type) {
+	case *lox.PrintStatement:
+		c.line = n.Line
+		c.compileNode(n.Expression)
+		c.emit(OP_PRINT)
+	case *lox.ExpressionStatement:
+		c.line = n.Line
+		c.compileNode(n.Expression)
+		c.emit(OP_POP)
+	case *lox.BlockStmt:
+		c.line = n.Line
+		c.beginScope()
+		for _, stmt := range n.Statements {
+			c.declareAssigned(stmt)
+			c.compileNode(stmt)
+		}
+		c.line = n.EndLine
+		c.endScope()
+	case *lox.VarStmt:
+		c.compileVarStmt(n)
+	case *lox.AssignStmt:
+		c.compileNode(n.Value)
+		c.line = n.Line
+		if c.scopeDepth == 0 {
+			c.emitBytes(OP_DEFINE_GLOBAL, c.identifierConstant(n.Name))
+			c.emitBytes(OP_GET_GLOBAL, c.identifierConstant(n.Name))
+			break
+		}
+		slot := c.scopeLocal(n.Name)
+		c.locals[slot].hidden = false
+		c.emitBytes(OP_SET_LOCAL, byte(slot))
+	case *lox.Identifier:
+		c.line = n.Line
+		if slot := c.resolveLocal(n.Name); slot >= 0 {
+			c.emitBytes(OP_GET_LOCAL, byte(slot))
+		} else {
+			c.emitBytes(OP_GET_GLOBAL, c.identifierConstant(n.Name))
+		}
+	case *lox.Literal:
+		c.line = n.Line
+		c.compileLiteral(n)
+	case *lox.Grouping:
+		c.compileNode(n.Expression)
+	case *lox.Unary:
+		c.compileNode(n.Right)
+		c.line = n.Line
+		switch n.Operator.Type {
+		case "BANG":
+			c.emit(OP_NOT)
+		case "MINUS":
+			c.emit(OP_NEGATE)
+		}
+	case *lox.Binary:
+		c.compileNode(n.Left)
+		c.compileNode(n.Right)
+		c.line = n.Line
+		c.compileBinaryOperator(n.Operator.Type)
+	case *lox.Call:
+		c.compileNode(n.Callee)
+		for _, argument := range n.Arguments {
+			c.compileNode(argument)
+		}
+		if len(n.Arguments) > 255 {
+			c.error("Can't have more than 255 arguments.")
+		}
+		c.line = n.Line
+		c.emitBytes(OP_CALL, byte(len(n.Arguments)))
+	case *lox.Get:
+		c.compileNode(n.Object)
+		c.line = n.Line
+		c.emitBytes(OP_GET_PROPERTY, c.identifierConstant(n.Name))
+	case *lox.Set:
+		c.compileNode(n.Object)
+		c.compileNode(n.Value)
+		c.line = n.Line
+		c.emitBytes(OP_SET_PROPERTY, c.identifierConstant(n.Name))
+	case *lox.ListLiteral:
+		for _, element := range n.Elements {
+			c.compileNode(element)
+		}
+		if len(n.Elements) > 255 {
+			c.error("Can't have more than 255 elements in a list literal.")
+		}
+		c.line = n.Line
+		c.emitBytes(OP_BUILD_LIST, byte(len(n.Elements)))
+	case *lox.MapLiteral:
+		for i := range n.Keys {
+			c.compileNode(n.Keys[i])
+			c.compileNode(n.Values[i])
+		}
+		if len(n.Keys) > 255 {
+			c.error("Can't have more than 255 entries in a map literal.")
+		}
+		c.line = n.Line
+		c.emitBytes(OP_BUILD_MAP, byte(len(n.Keys)))
+	case *lox.Index:
+		c.compileNode(n.Object)
+		c.compileNode(n.Index)
+		c.line = n.Line
+		c.emit(OP_GET_INDEX)
+	case *lox.SetIndex:
+		c.compileNode(n.Object)
+		c.compileNode(n.Index)
+		c.compileNode(n.Value)
+		c.line = n.Line
+		c.emit(OP_SET_INDEX)
+	case *lox.ImportStmt:
+		c.line = n.Line
+		c.emitBytes(OP_IMPORT, c.makeConstant(n.Path))
+		if n.Name != "" {
+			c.defineVariable(n.Name)
+		} else {
+			// The names of a module are only known once it has run, so
+			// they cannot get stack slots and are defined as globals
+			c.emit(OP_IMPORT_NAMES)
+		}
+	default:
+		c.error(fmt.Sprintf("Cannot compile %T.", node))
+	}
+}
+
+// compileVarStmt handles both declarations (var x = 1;) and the bare
+// assignment statements (x = 1;) that the parser also turns into VarStmt.
+// An assignment declares the variable in the current scope too, once it is
+// known to exist in some scope.
+func (c *Compiler) compileVarStmt(v *lox.VarStmt) {
+	if !v.VarUsed && c.resolveLocal(v.Name) < 0 {
+		c.line = v.Line
+		c.emitBytes(OP_GET_GLOBAL, c.identifierConstant(v.Name))
+		c.emit(OP_POP)
+	}
+	if v.Initializer != nil {
+		c.compileNode(v.Initializer)
+	} else {
+		c.line = v.Line
+		c.emit(OP_NIL)
+	}
+	c.line = v.Line
+	c.defineVariable(v.Name)
+}
+
+// defineVariable declares a variable holding the value on top of the stack:
+// a global at the top level, else a local whose slot is that stack entry
+func (c *Compiler) defineVariable(name string) {
+	if c.scopeDepth == 0 {
+		c.emitBytes(OP_DEFINE_GLOBAL, c.identifierConstant(name))
+		return
+	}
+
+	// Redeclaring a variable in the same block reuses its slot
+	if slot := c.scopeLocal(name); slot >= 0 {
+		c.locals[slot].hidden = false
+		c.emitBytes(OP_SET_LOCAL, byte(slot))
+		c.emit(OP_POP)
+		return
+	}
+	if len(c.locals) == 256 {
+		c.error("Too many local variables in function.")
+	}
+	c.locals = append(c.locals, local{name: name, depth: c.scopeDepth})
+}
+
+// compileLiteral loads a literal value onto the stack
+func (c *Compiler) compileLiteral(l *lox.Literal) {
+	switch l.Type {
+	case "nil":
+		c.emit(OP_NIL)
+	case "boolean":
+		if l.Value == true {
+			c.emit(OP_TRUE)
+		} else {
+			c.emit(OP_FALSE)
+		}
+	case "number":
+		value, err := lox.ConvertStringToFloat(fmt.Sprint(l.Value), l.Line)
+		if err != nil {
+			c.error("Invalid number.")
+		}
+		c.emitConstant(value)
+	default:
+		c.emitConstant(l.Value)
+	}
+}
+
+// compileBinaryOperator emits the instructions for a binary operator. As in
+// clox, !=, <= and >= are expressed through their negated counterparts.
+func (c *Compiler) compileBinaryOperator(operator string) {
+	switch operator {
+	case "PLUS":
+		c.emit(OP_ADD)
+	case "MINUS":
+		c.emit(OP_SUBTRACT)
+	case "STAR":
+		c.emit(OP_MULTIPLY)
+	case "SLASH":
+		c.emit(OP_DIVIDE)
+	case "EQUAL_EQUAL":
+		c.emit(OP_EQUAL)
+	case "BANG_EQUAL":
+		c.emit(OP_EQUAL)
+		c.emit(OP_NOT)
+	case "GREATER":
+		c.emit(OP_GREATER)
+	case "GREATER_EQUAL":
+		c.emit(OP_LESS)
+		c.emit(OP_NOT)
+	case "LESS":
+		c.emit(OP_LESS)
+	case "LESS_EQUAL":
+		c.emit(OP_GREATER)
+		c.emit(OP_NOT)
+	}
+}
+
+// declareAssigned takes a slot for every variable an assignment inside stmt
+// defines in the current block. The slot must be below the temporaries of
+// the statement, so it is taken before the statement runs, holding nil, and
+// stays hidden until the assignment.
+func (c *Compiler) declareAssigned(stmt lox.Stmt) {
+	line, _ := lox.LineRange(stmt)
+	for _, name := range assignedNames(stmt, nil) {
+		if c.scopeLocal(name) >= 0 {
+			continue
+		}
+		if len(c.locals) == 256 {
+			c.error("Too many local variables in function.")
+		}
+		c.line = line
+		c.emit(OP_NIL)
+		c.locals = append(c.locals, local{name: name, depth: c.scopeDepth, hidden: true})
+	}
+}
+
+// assignedNames appends the names assigned by the assignment expressions in
+// node to names, blocks excepted
+func assignedNames(node lox.Expr, names []string) []string {
+	switch n := node.(type) {
+	case *lox.AssignStmt:
+		names = assignedNames(n.Value, names)
+		names = append(names, n.Name)
+	case *lox.PrintStatement:
+		names = assignedNames(n.Expression, names)
+	case *lox.ExpressionStatement:
+		names = assignedNames(n.Expression, names)
+	case *lox.VarStmt:
+		if n.Initializer != nil {
+			names = assignedNames(n.Initializer, names)
+		}
+	case *lox.Grouping:
+		names = assignedNames(n.Expression, names)
+	case *lox.Unary:
+		names = assignedNames(n.Right, names)
+	case *lox.Binary:
+		names = assignedNames(n.Left, names)
+		names = assignedNames(n.Right, names)
+	case *lox.Call:
+		names = assignedNames(n.Callee, names)
+		for _, argument := range n.Arguments {
+			names = assignedNames(argument, names)
+		}
+	case *lox.Get:
+		names = assignedNames(n.Object, names)
+	case *lox.Set:
+		names = assignedNames(n.Object, names)
+		names = assignedNames(n.Value, names)
+	case *lox.ListLiteral:
+		for _, element := range n.Elements {
+			names = assignedNames(element, names)
+		}
+	case *lox.MapLiteral:
+		for i := range n.Keys {
+			names = assignedNames(n.Keys[i], names)
+			names = assignedNames(n.Values[i], names)
+		}
+	case *lox.Index:
+		names = assignedNames(n.Object, names)
+		names = assignedNames(n.Index, names)
+	case *lox.SetIndex:
+		names = assignedNames(n.Object, names)
+		names = assignedNames(n.Index, names)
+		names = assignedNames(n.Value, names)
+	}
+	return names
+}
+
+// resolveLocal returns the stack slot of a local variable, or -1 for globals
+func (c *Compiler) resolveLocal(name string) int {
+	for i := len(c.locals) - 1; i >= 0; i-- {
+		if c.locals[i].name == name && !c.locals[i].hidden {
+			return i
+		}
+	}
+	return -1
+}
+
+// scopeLocal returns the slot of a variable of the innermost scope, hidden
+// or not, or -1
+func (c *Compiler) scopeLocal(name string) int {
+	for i := len(c.locals) - 1; i >= 0 && c.locals[i].depth == c.scopeDepth; i-- {
+		if c.locals[i].name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (c *Compiler) beginScope() {
+	c.scopeDepth++
+}
+
+// endScope pops every local declared in the scope being closed
+func (c *Compiler) endScope() {
+	c.scopeDepth--
+	for len(c.locals) > 0 && c.locals[len(c.locals)-1].depth > c.scopeDepth {
+		c.emit(OP_POP)
+		c.locals = c.locals[:len(c.locals)-1]
+	}
+}
+
+func (c *Compiler) identifierConstant(name string) byte {
+	return c.makeConstant(name)
+}
+
+func (c *Compiler) makeConstant(value interface{}) byte {
+	index := c.function.Chunk.AddConstant(value)
+	if index > 255 {
+		c.error("Too many constants in one chunk.")
+	}
+	return byte(index)
+}
+
+func (c *Compiler) emitConstant(value interface{}) {
+	c.emitBytes(OP_CONSTANT, c.makeConstant(value))
+}
+
+func (c *Compiler) emit(op OpCode) {
+	c.function.Chunk.Write(byte(op), c.line)
+}
+
+func (c *Compiler) emitBytes(op OpCode, operand byte) {
+	c.emit(op)
+	c.function.Chunk.Write(operand, c.line)
+}
+
+func (c *Compiler) error(msg string) {
+	fmt.Fprintf(os.Stderr, "[line %d] Error: %s\n", c.line, msg)
+	os.Exit(65)
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/codecrafters-io/interpreter-starter-go/lox"
+)
+
+// disassemble compiles source and returns its listing without the offset
+// and line columns
+func disassemble(t *testing.T, source string) string {
+	t.Helper()
+	interpreter := lox.New(lox.Options{Stdout: io.Discard, Stderr: io.Discard})
+	statements, err := interpreter.Parse(source)
+	if err != nil {
+		t.Fatalf("%s: %v", source, err)
+	}
+	var listing strings.Builder
+	DisassembleFunction(&listing, NewCompiler().Compile(statements))
+
+	lines := []string{}
+	for _, line := range strings.Split(strings.TrimSpace(listing.String()), "\n")[1:] {
+		lines = append(lines, strings.Join(strings.Fields(line[10:]), " "))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// compileTest is a program and its listing, without the final return
+type compileTest struct {
+	source string
+	want   string
+}
+
+func checkCompiled(t *testing.T, tests []compileTest) {
+	t.Helper()
+	for _, test := range tests {
+		want := strings.TrimSpace(test.want) + "\nOP_NIL\nOP_RETURN"
+		if got := disassemble(t, test.source); got != want {
+			t.Errorf("%s compiled to\n%s\nwant\n%s", test.source, got, want)
+		}
+	}
+}
+
+func TestCompileImports(t *testing.T) {
+	checkCompiled(t, []compileTest{
+		{`import "a.lox";`, `
+OP_IMPORT 0 'a.lox'
+OP_IMPORT_NAMES`},
+		{`import a from "a.lox"; print a;`, `
+OP_IMPORT 0 'a.lox'
+OP_DEFINE_GLOBAL 1 'a'
+OP_GET_GLOBAL 2 'a'
+OP_PRINT`},
+		{`{ import a from "a.lox"; print a; }`, `
+OP_IMPORT 0 'a.lox'
+OP_GET_LOCAL 1
+OP_PRINT
+OP_POP`},
+	})
+}
+
+func TestCompileLists(t *testing.T) {
+	checkCompiled(t, []compileTest{
+		{`print [];`, `
+OP_BUILD_LIST 0
+OP_PRINT`},
+		{`var xs = [1, [2]]; xs[1][0] = xs[0];`, `
+OP_CONSTANT 0 '1'
+OP_CONSTANT 1 '2'
+OP_BUILD_LIST 1
+OP_BUILD_LIST 2
+OP_DEFINE_GLOBAL 2 'xs'
+OP_GET_GLOBAL 3 'xs'
+OP_CONSTANT 4 '1'
+OP_GET_INDEX
+OP_CONSTANT 5 '0'
+OP_GET_GLOBAL 6 'xs'
+OP_CONSTANT 7 '0'
+OP_GET_INDEX
+OP_SET_INDEX
+OP_POP`},
+	})
+}
+
+func TestCompileMaps(t *testing.T) {
+	checkCompiled(t, []compileTest{
+		{`print {};`, `
+OP_BUILD_MAP 0
+OP_PRINT`},
+		{`var m = {"a": 1, nil: [2]}; print m["a"];`, `
+OP_CONSTANT 0 'a'
+OP_CONSTANT 1 '1'
+OP_NIL
+OP_CONSTANT 2 '2'
+OP_BUILD_LIST 1
+OP_BUILD_MAP 2
+OP_DEFINE_GLOBAL 3 'm'
+OP_GET_GLOBAL 4 'm'
+OP_CONSTANT 5 'a'
+OP_GET_INDEX
+OP_PRINT`},
+	})
+}
+
+func TestCompileAssignmentsDefineVariables(t *testing.T) {
+	checkCompiled(t, []compileTest{
+		// The variable must exist before it is assigned
+		{`var x; x = 1;`, `
+OP_NIL
+OP_DEFINE_GLOBAL 0 'x'
+OP_GET_GLOBAL 1 'x'
+OP_POP
+OP_CONSTANT 2 '1'
+OP_DEFINE_GLOBAL 3 'x'`},
+		// Slot 0 is reserved, and assigning in a block shadows the global
+		{`var x = 1; { var y = 2; x = y; print x; } print x;`, `
+OP_CONSTANT 0 '1'
+OP_DEFINE_GLOBAL 1 'x'
+OP_CONSTANT 2 '2'
+OP_GET_GLOBAL 3 'x'
+OP_POP
+OP_GET_LOCAL 1
+OP_GET_LOCAL 2
+OP_PRINT
+OP_POP
+OP_POP
+OP_GET_GLOBAL 4 'x'
+OP_PRINT`},
+		{`{ var x = 1; x = 2; }`, `
+OP_CONSTANT 0 '1'
+OP_CONSTANT 1 '2'
+OP_SET_LOCAL 1
+OP_POP
+OP_POP`},
+		{`var a; print a = 1;`, `
+OP_NIL
+OP_DEFINE_GLOBAL 0 'a'
+OP_CONSTANT 1 '1'
+OP_DEFINE_GLOBAL 2 'a'
+OP_GET_GLOBAL 3 'a'
+OP_PRINT`},
+		// The slot of a variable assigned in an expression is taken before
+		// the statement, and reads before the assignment see the global
+		{`var a = 1; { f(a, a = 2); print a; }`, `
+OP_CONSTANT 0 '1'
+OP_DEFINE_GLOBAL 1 'a'
+OP_NIL
+OP_GET_GLOBAL 2 'f'
+OP_GET_GLOBAL 3 'a'
+OP_CONSTANT 4 '2'
+OP_SET_LOCAL 1
+OP_CALL 2
+OP_POP
+OP_GET_LOCAL 1
+OP_PRINT
+OP_POP`},
+	})
+}
@@ -0,0 +1,236 @@
+// coverage.go
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/interpreter-starter-go/lox"
+)
+
+// LCOVRecord is the line coverage of one source file: how many times each
+// line holding a statement was executed
+type LCOVRecord struct {
+	File  string
+	Lines map[int]int
+}
+
+// Coverage counts the statements executed by a program and the modules it
+// imports
+type Coverage struct {
+	Records []*LCOVRecord // the program first, then modules as they are imported
+	open    []sourceLine  // lines of the statements being executed, innermost last
+}
+
+// NewCoverage creates a coverage counter for a program. Every line a
+// statement starts on is instrumented and starts with a count of 0.
+func NewCoverage(filename string, statements []lox.Stmt) *Coverage {
+	return &Coverage{Records: []*LCOVRecord{newLCOVRecord(filename, statements)}}
+}
+
+func newLCOVRecord(filename string, statements []lox.Stmt) *LCOVRecord {
+	record := &LCOVRecord{File: filename, Lines: map[int]int{}}
+	for line := range lox.StatementLines(statements) {
+		record.Lines[line] = 0
+	}
+	return record
+}
+
+// Attach installs the coverage counter on an environment, its nested scopes
+// and the modules it imports. A line counts once each time it runs, so a
+// statement inside a block that starts on the same line does not count it
+// again.
+func (c *Coverage) Attach(env *lox.Environment) {
+	env.Hooks.BeforeStmt = append(env.Hooks.BeforeStmt, func(stmt lox.Stmt, env *lox.Environment) {
+		line, _ := lox.LineRange(stmt)
+		if block, ok := stmt.(*lox.BlockStmt); ok {
+			line = block.Line
+		}
+		position := sourceLine{env.File(), line}
+		if len(c.open) == 0 || c.open[len(c.open)-1] != position {
+			c.record(position.file).Lines[line]++
+		}
+		c.open = append(c.open, position)
+	})
+	env.Hooks.AfterStmt = append(env.Hooks.AfterStmt, func(stmt lox.Stmt, env *lox.Environment, value interface{}) {
+		c.open = c.open[:len(c.open)-1]
+	})
+}
+
+// record returns the record of a file, reading the statements of a module
+// the first time it runs. Code without a file counts towards the program.
+func (c *Coverage) record(file string) *LCOVRecord {
+	if file == "" {
+		return c.Records[0]
+	}
+	for _, record := range c.Records {
+		if record.File == file {
+			return record
+		}
+	}
+	// The module was parsed before it ran, so only a file changed since
+	// then can fail here; its lines are then only those that ran
+	var statements []lox.Stmt
+	if source, err := os.ReadFile(file); err == nil {
+		interpreter := lox.New(lox.Options{Stdout: io.Discard, Stderr: io.Discard})
+		statements, _ = interpreter.Parse(string(source))
+	}
+	record := newLCOVRecord(file, statements)
+	c.Records = append(c.Records, record)
+	return record
+}
+
+// Uncovered returns the instrumented lines that never ran, in order
+func (r *LCOVRecord) Uncovered() []int {
+	lines := []int{}
+	for line, count := range r.Lines {
+		if count == 0 {
+			lines = append(lines, line)
+		}
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+// Hit returns the number of instrumented lines that ran at least once
+func (r *LCOVRecord) Hit() int {
+	return len(r.Lines) - len(r.Uncovered())
+}
+
+// ReadLCOV reads the SF and DA entries of an LCOV file. Other entries are
+// ignored since only line coverage is recorded.
+func ReadLCOV(in io.Reader) ([]*LCOVRecord, error) {
+	records := []*LCOVRecord{}
+	var record *LCOVRecord
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case strings.HasPrefix(line, "SF:"):
+			record = &LCOVRecord{File: strings.TrimPrefix(line, "SF:"), Lines: map[int]int{}}
+			records = append(records, record)
+		case strings.HasPrefix(line, "DA:"):
+			fields := strings.Split(strings.TrimPrefix(line, "DA:"), ",")
+			if record == nil || len(fields) < 2 {
+				return nil, fmt.Errorf("invalid LCOV line '%s'", line)
+			}
+			number, err := strconv.Atoi(fields[0])
+			if err != nil {
+				return nil, fmt.Errorf("invalid LCOV line '%s'", line)
+			}
+			count, err := strconv.Atoi(fields[1])
+			if err != nil {
+				return nil, fmt.Errorf("invalid LCOV line '%s'", line)
+			}
+			record.Lines[number] += count
+		case line == "end_of_record":
+			record = nil
+		}
+	}
+	return records, scanner.Err()
+}
+
+// WriteLCOV writes records in the LCOV tracefile format
+func WriteLCOV(out io.Writer, records []*LCOVRecord) error {
+	w := bufio.NewWriter(out)
+	for _, record := range records {
+		lines := []int{}
+		for line := range record.Lines {
+			lines = append(lines, line)
+		}
+		sort.Ints(lines)
+
+		fmt.Fprintln(w, "TN:")
+		fmt.Fprintf(w, "SF:%s\n", record.File)
+		for _, line := range lines {
+			fmt.Fprintf(w, "DA:%d,%d\n", line, record.Lines[line])
+		}
+		fmt.Fprintf(w, "LF:%d\n", len(lines))
+		fmt.Fprintf(w, "LH:%d\n", record.Hit())
+		fmt.Fprintln(w, "end_of_record")
+	}
+	return w.Flush()
+}
+
+// MergeLCOV adds the counts of record to the record of the same file, or
+// appends it, and returns the merged record
+func MergeLCOV(records []*LCOVRecord, record *LCOVRecord) ([]*LCOVRecord, *LCOVRecord) {
+	for _, existing := range records {
+		if existing.File == record.File {
+			for line, count := range record.Lines {
+				existing.Lines[line] += count
+			}
+			return records, existing
+		}
+	}
+	return append(records, record), record
+}
+
+// writeCoverage merges the coverage of a run into an LCOV file, creating it
+// if needed, and prints a summary of the uncovered lines of each file to
+// stderr
+func writeCoverage(filename string, run []*LCOVRecord) error {
+	records := []*LCOVRecord{}
+	if file, err := os.Open(filename); err == nil {
+		records, err = ReadLCOV(file)
+		file.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %v", filename, err)
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+	merged := []*LCOVRecord{}
+	for _, record := range run {
+		var total *LCOVRecord
+		records, total = MergeLCOV(records, record)
+		merged = append(merged, total)
+	}
+
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := WriteLCOV(file, records); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+
+	for _, record := range merged {
+		percent := 100.0
+		if len(record.Lines) > 0 {
+			percent = 100 * float64(record.Hit()) / float64(len(record.Lines))
+		}
+		fmt.Fprintf(os.Stderr, "Coverage: %d of %d lines (%.1f%%) in %s\n", record.Hit(), len(record.Lines), percent, record.File)
+		if uncovered := record.Uncovered(); len(uncovered) > 0 {
+			fmt.Fprintf(os.Stderr, "Uncovered lines: %s\n", lineRanges(uncovered))
+		}
+	}
+	return nil
+}
+
+// lineRanges formats sorted line numbers compactly, such as "3-5, 9"
+func lineRanges(lines []int) string {
+	parts := []string{}
+	for i := 0; i < len(lines); {
+		j := i
+		for j+1 < len(lines) && lines[j+1] == lines[j]+1 {
+			j++
+		}
+		if i == j {
+			parts = append(parts, strconv.Itoa(lines[i]))
+		} else {
+			parts = append(parts, fmt.Sprintf("%d-%d", lines[i], lines[j]))
+		}
+		i = j + 1
+	}
+	return strings.Join(parts, ", ")
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"context"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/codecrafters-io/interpreter-starter-go/lox"
+)
+
+func TestCoverageCountsEachLineOncePerRun(t *testing.T) {
+	interpreter := lox.New(lox.Options{Stdout: io.Discard, Stderr: io.Discard})
+	statements, err := interpreter.Parse("{ print 1; { print 2; } }\n{\n  print 3;\n}\nprint nil + 1;\nprint 4;\n")
+	if err != nil {
+		t.Fatal(err)
+	}
+	counter := NewCoverage("test.lox", statements)
+	counter.Attach(interpreter.Globals)
+	interpreter.Execute(context.Background(), statements)
+
+	if want := map[int]int{1: 1, 2: 1, 3: 1, 5: 1, 6: 0}; !reflect.DeepEqual(counter.Records[0].Lines, want) {
+		t.Errorf("got line counts %v, want %v", counter.Records[0].Lines, want)
+	}
+}
@@ -0,0 +1,386 @@
+// dap.go
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"sync/atomic"
+
+	"github.com/codecrafters-io/interpreter-starter-go/lox"
+)
+
+// DAP_THREAD_ID is the only thread a Lox program runs on
+const DAP_THREAD_ID = 1
+
+// dapRequest is an incoming Debug Adapter Protocol request
+type dapRequest struct {
+	Seq       int             `json:"seq"`
+	Type      string          `json:"type"`
+	Command   string          `json:"command"`
+	Arguments json.RawMessage `json:"arguments"`
+}
+
+// DebugAdapter serves the Debug Adapter Protocol on top of the Debugger. The
+// program runs on its own goroutine; while it is paused the protocol loop
+// answers stackTrace, scopes, variables and evaluate requests from the
+// frames the debugger recorded.
+type DebugAdapter struct {
+	in  *bufio.Reader
+	out io.Writer
+
+	writeMu sync.Mutex
+	seq     int
+
+	debugger   *Debugger
+	program    string
+	options    scriptOptions
+	statements []lox.Stmt
+	launched   bool
+	paused     atomic.Bool
+	resume     chan StepMode
+	scopes     map[int]*lox.Environment // variablesReference -> scope, valid while paused
+}
+
+// NewDebugAdapter creates an adapter reading requests from in and writing
+// responses and events to out
+func NewDebugAdapter(in io.Reader, out io.Writer) *DebugAdapter {
+	return &DebugAdapter{
+		in:     bufio.NewReader(in),
+		out:    out,
+		resume: make(chan StepMode),
+		scopes: map[int]*lox.Environment{},
+	}
+}
+
+// Serve handles requests until the client disconnects
+func (a *DebugAdapter) Serve() int {
+	for {
+		request := &dapRequest{}
+		if err := readFramedMessage(a.in, request); err != nil {
+			if err != io.EOF {
+				fmt.Fprintf(os.Stderr, "dap: %v\n", err)
+			}
+			return 1
+		}
+		if request.Type != "request" {
+			continue
+		}
+
+		body, err := a.handle(request)
+		a.respond(request, body, err)
+
+		switch request.Command {
+		case "initialize":
+			a.sendEvent("initialized", nil)
+		case "configurationDone":
+			a.start()
+		case "disconnect", "terminate":
+			return 0
+		}
+	}
+}
+
+// handle runs a request and returns the response body
+func (a *DebugAdapter) handle(request *dapRequest) (interface{}, error) {
+	switch request.Command {
+	case "initialize":
+		return jsonObject{
+			{"supportsConfigurationDoneRequest", true},
+			{"supportsEvaluateForHovers", true},
+		}, nil
+
+	case "launch":
+		// The capabilities and import path are those of the run flags
+		var args struct {
+			Program     string   `json:"program"`
+			StopOnEntry bool     `json:"stopOnEntry"`
+			AllowRead   []string `json:"allowRead"`
+			AllowWrite  []string `json:"allowWrite"`
+			AllowEnv    []string `json:"allowEnv"`
+			AllowExec   bool     `json:"allowExec"`
+			ImportPath  []string `json:"importPath"`
+		}
+		if err := json.Unmarshal(request.Arguments, &args); err != nil {
+			return nil, err
+		}
+		source, err := os.ReadFile(args.Program)
+		if err != nil {
+			return nil, err
+		}
+		statements, err := parseForDebugging(string(source))
+		if err != nil {
+			return nil, err
+		}
+		a.program = args.Program
+		a.options = scriptOptions{
+			capabilities: lox.Capabilities{
+				Read:  args.AllowRead,
+				Write: args.AllowWrite,
+				Env:   args.AllowEnv,
+				Exec:  args.AllowExec,
+			},
+			importPath: args.ImportPath,
+		}
+		a.statements = statements
+		a.debugger = NewDebugger(args.StopOnEntry)
+		a.debugger.OnPause = a.pause
+		a.launched = true
+		return nil, nil
+
+	case "setBreakpoints":
+		var args struct {
+			Breakpoints []struct {
+				Line int `json:"line"`
+			} `json:"breakpoints"`
+		}
+		if err := json.Unmarshal(request.Arguments, &args); err != nil {
+			return nil, err
+		}
+		if !a.launched {
+			return nil, fmt.Errorf("no program launched")
+		}
+		lines := []int{}
+		verified := lox.StatementLines(a.statements)
+		breakpoints := []jsonObject{}
+		for _, breakpoint := range args.Breakpoints {
+			lines = append(lines, breakpoint.Line)
+			breakpoints = append(breakpoints, jsonObject{
+				{"verified", verified[breakpoint.Line]},
+				{"line", breakpoint.Line},
+			})
+		}
+		a.debugger.SetBreakpoints(lines)
+		return jsonObject{{"breakpoints", breakpoints}}, nil
+
+	case "configurationDone", "disconnect", "terminate":
+		return nil, nil
+
+	case "threads":
+		return jsonObject{{"threads", []jsonObject{{{"id", DAP_THREAD_ID}, {"name", "main"}}}}}, nil
+
+	case "stackTrace":
+		if !a.paused.Load() {
+			return nil, fmt.Errorf("program is not paused")
+		}
+		frames := []jsonObject{}
+		for i := len(a.debugger.Frames) - 1; i >= 0; i-- {
+			frames = append(frames, jsonObject{
+				{"id", i},
+				{"name", a.debugger.FrameName(i)},
+				{"line", a.debugger.Frames[i].Line},
+				{"column", 1},
+				{"source", jsonObject{{"name", filepath.Base(a.program)}, {"path", a.program}}},
+			})
+		}
+		return jsonObject{{"stackFrames", frames}, {"totalFrames", len(frames)}}, nil
+
+	case "scopes":
+		var args struct {
+			FrameID int `json:"frameId"`
+		}
+		if err := json.Unmarshal(request.Arguments, &args); err != nil {
+			return nil, err
+		}
+		if !a.paused.Load() || args.FrameID < 0 || args.FrameID >= len(a.debugger.Frames) {
+			return nil, fmt.Errorf("unknown frame %d", args.FrameID)
+		}
+		scopes := []jsonObject{}
+		env := a.debugger.Frames[args.FrameID].Env
+		for depth := env.Depth(); env != nil; env, depth = env.Parent, depth-1 {
+			reference := len(a.scopes) + 1
+			a.scopes[reference] = env
+			name := fmt.Sprintf("Block %d", depth)
+			if depth == 0 {
+				name = "Globals"
+			}
+			scopes = append(scopes, jsonObject{
+				{"name", name},
+				{"variablesReference", reference},
+				{"expensive", false},
+			})
+		}
+		return jsonObject{{"scopes", scopes}}, nil
+
+	case "variables":
+		var args struct {
+			VariablesReference int `json:"variablesReference"`
+		}
+		if err := json.Unmarshal(request.Arguments, &args); err != nil {
+			return nil, err
+		}
+		env, ok := a.scopes[args.VariablesReference]
+		if !a.paused.Load() || !ok {
+			return nil, fmt.Errorf("unknown variables reference %d", args.VariablesReference)
+		}
+		names := []string{}
+		for name := range env.Values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		variables := []jsonObject{}
+		for _, name := range names {
+			variables = append(variables, jsonObject{
+				{"name", name},
+				{"value", lox.Stringify(env.Values[name])},
+				{"variablesReference", 0},
+			})
+		}
+		return jsonObject{{"variables", variables}}, nil
+
+	case "evaluate":
+		var args struct {
+			Expression string `json:"expression"`
+			FrameID    *int   `json:"frameId"`
+		}
+		if err := json.Unmarshal(request.Arguments, &args); err != nil {
+			return nil, err
+		}
+		if !a.paused.Load() {
+			return nil, fmt.Errorf("program is not paused")
+		}
+		frame := len(a.debugger.Frames) - 1
+		if args.FrameID != nil && *args.FrameID >= 0 && *args.FrameID < len(a.debugger.Frames) {
+			frame = *args.FrameID
+		}
+		value, err := a.debugger.Evaluate(args.Expression, frame)
+		if err != nil {
+			return nil, err
+		}
+		return jsonObject{{"result", lox.Stringify(value)}, {"variablesReference", 0}}, nil
+
+	case "continue", "next", "stepIn", "stepOut":
+		if !a.paused.Load() {
+			return nil, fmt.Errorf("program is not paused")
+		}
+		mode := map[string]StepMode{
+			"continue": STEP_CONTINUE,
+			"next":     STEP_OVER,
+			"stepIn":   STEP_INTO,
+			"stepOut":  STEP_OUT,
+		}[request.Command]
+		a.paused.Store(false)
+		a.scopes = map[int]*lox.Environment{}
+		a.resume <- mode
+		if request.Command == "continue" {
+			return jsonObject{{"allThreadsContinued", true}}, nil
+		}
+		return nil, nil
+
+	case "pause":
+		if a.launched && !a.paused.Load() {
+			a.debugger.RequestPause()
+		}
+		return nil, nil
+	}
+
+	return nil, fmt.Errorf("unsupported request '%s'", request.Command)
+}
+
+// start runs the launched program on its own goroutine
+func (a *DebugAdapter) start() {
+	if !a.launched {
+		return
+	}
+	go func() {
+		exitCode := a.runProgram()
+		a.sendEvent("exited", jsonObject{{"exitCode", exitCode}})
+		a.sendEvent("terminated", nil)
+	}()
+}
+
+// dapOutput forwards what the program writes to the client as output
+// events, since stdout carries the protocol
+type dapOutput struct {
+	adapter  *DebugAdapter
+	category string
+}
+
+func (o dapOutput) Write(p []byte) (int, error) {
+	o.adapter.sendOutput(o.category, string(p))
+	return len(p), nil
+}
+
+// runProgram runs the statements in the interpreter the run command uses
+// and returns the exit status
+func (a *DebugAdapter) runProgram() int {
+	options := a.options.interpreterOptions(a.program)
+	options.Stdout = dapOutput{a, "stdout"}
+	options.Stderr = dapOutput{a, "stderr"}
+	interpreter := lox.New(options)
+	a.debugger.Attach(interpreter.Globals)
+	_, err := interpreter.Execute(context.Background(), a.statements)
+	return lox.ExitCode(err)
+}
+
+// pause runs on the program goroutine: it reports the stop to the client
+// and blocks until a continue or step request arrives
+func (a *DebugAdapter) pause(reason string) StepMode {
+	a.paused.Store(true)
+	a.sendEvent("stopped", jsonObject{
+		{"reason", reason},
+		{"threadId", DAP_THREAD_ID},
+		{"allThreadsStopped", true},
+	})
+	return <-a.resume
+}
+
+func (a *DebugAdapter) respond(request *dapRequest, body interface{}, err error) {
+	response := jsonObject{
+		{"type", "response"},
+		{"request_seq", request.Seq},
+		{"command", request.Command},
+		{"success", err == nil},
+	}
+	if err != nil {
+		response = append(response, jsonField{"message", err.Error()})
+	}
+	if body != nil {
+		response = append(response, jsonField{"body", body})
+	}
+	a.send(response)
+}
+
+func (a *DebugAdapter) sendEvent(event string, body interface{}) {
+	message := jsonObject{{"type", "event"}, {"event", event}}
+	if body != nil {
+		message = append(message, jsonField{"body", body})
+	}
+	a.send(message)
+}
+
+func (a *DebugAdapter) sendOutput(category, output string) {
+	a.sendEvent("output", jsonObject{{"category", category}, {"output", output}})
+}
+
+// send numbers and writes a message; both goroutines send messages
+func (a *DebugAdapter) send(message jsonObject) {
+	a.writeMu.Lock()
+	defer a.writeMu.Unlock()
+	a.seq++
+	message = append(jsonObject{{"seq", a.seq}}, message...)
+	if err := writeFramedMessage(a.out, message); err != nil {
+		fmt.Fprintf(os.Stderr, "dap: %v\n", err)
+	}
+}
+
+// parseForDebugging scans and parses a program, returning the first syntax
+// error instead of exiting
+func parseForDebugging(source string) ([]lox.Stmt, error) {
+	scanner := lox.NewLexer(source, false)
+	scanner.Scan()
+	if len(scanner.Errors()) > 0 {
+		return nil, scanner.Errors()[0]
+	}
+	statements, parseErrors := lox.NewParser(scanner, "run").ParseWithErrors()
+	if len(parseErrors) > 0 {
+		return nil, parseErrors[0]
+	}
+	return statements, nil
+}
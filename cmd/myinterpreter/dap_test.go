@@ -0,0 +1,268 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// dapMessage is a response or event sent by the adapter
+type dapMessage struct {
+	Type       string          `json:"type"`
+	RequestSeq int             `json:"request_seq"`
+	Command    string          `json:"command"`
+	Success    bool            `json:"success"`
+	Message    string          `json:"message"`
+	Event      string          `json:"event"`
+	Body       json.RawMessage `json:"body"`
+}
+
+// dapClient drives a DebugAdapter over framed pipes the way an editor does,
+// waiting for each response and event
+type dapClient struct {
+	t        *testing.T
+	in       *io.PipeWriter
+	messages chan dapMessage
+	seq      int
+	events   []dapMessage // events received while waiting for responses
+}
+
+func newDAPClient(t *testing.T) (*dapClient, <-chan int) {
+	inReader, inWriter := io.Pipe()
+	outReader, outWriter := io.Pipe()
+	client := &dapClient{t: t, in: inWriter, messages: make(chan dapMessage, 100)}
+
+	status := make(chan int, 1)
+	go func() {
+		status <- NewDebugAdapter(inReader, outWriter).Serve()
+		outWriter.Close()
+	}()
+	go func() {
+		reader := bufio.NewReader(outReader)
+		for {
+			var message dapMessage
+			if err := readFramedMessage(reader, &message); err != nil {
+				close(client.messages)
+				return
+			}
+			client.messages <- message
+		}
+	}()
+	return client, status
+}
+
+// next returns the next message from the adapter
+func (c *dapClient) next() dapMessage {
+	c.t.Helper()
+	select {
+	case message, ok := <-c.messages:
+		if !ok {
+			c.t.Fatal("the adapter closed its output")
+		}
+		return message
+	case <-time.After(5 * time.Second):
+		c.t.Fatal("no message from the adapter")
+	}
+	return dapMessage{}
+}
+
+// request sends a request and returns its response, which must succeed
+func (c *dapClient) request(command string, arguments interface{}) dapMessage {
+	c.t.Helper()
+	c.seq++
+	request := map[string]interface{}{"seq": c.seq, "type": "request", "command": command, "arguments": arguments}
+	if err := writeFramedMessage(c.in, request); err != nil {
+		c.t.Fatal(err)
+	}
+	for {
+		message := c.next()
+		if message.Type == "event" {
+			c.events = append(c.events, message)
+			continue
+		}
+		if message.RequestSeq != c.seq || message.Command != command {
+			c.t.Fatalf("got a response to %s #%d, want %s #%d", message.Command, message.RequestSeq, command, c.seq)
+		}
+		if !message.Success {
+			c.t.Fatalf("%s failed: %s", command, message.Message)
+		}
+		return message
+	}
+}
+
+// waitEvent returns the first event of a kind, received before or now
+func (c *dapClient) waitEvent(event string) dapMessage {
+	c.t.Helper()
+	for i, message := range c.events {
+		if message.Event == event {
+			c.events = append(c.events[:i], c.events[i+1:]...)
+			return message
+		}
+	}
+	for {
+		message := c.next()
+		if message.Event == event {
+			return message
+		}
+		c.events = append(c.events, message)
+	}
+}
+
+func (c *dapClient) decode(message dapMessage, body interface{}) {
+	c.t.Helper()
+	if err := json.Unmarshal(message.Body, body); err != nil {
+		c.t.Fatalf("%s: %v", message.Body, err)
+	}
+}
+
+// output returns what the program wrote in the output events received so
+// far, each write prefixed with its category
+func (c *dapClient) output() string {
+	output := ""
+	for _, event := range c.events {
+		if event.Event == "output" {
+			var body struct{ Category, Output string }
+			c.decode(event, &body)
+			output += body.Category + ": " + body.Output
+		}
+	}
+	return output
+}
+
+func TestDebugAdapterSession(t *testing.T) {
+	program := filepath.Join(t.TempDir(), "program.lox")
+	source := "var a = 1;\n{\n  var b = a + 1;\n  print b;\n}\nprint a;\n"
+	if err := os.WriteFile(program, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	client, status := newDAPClient(t)
+
+	client.request("initialize", map[string]interface{}{"adapterID": "lox"})
+	client.waitEvent("initialized")
+	client.request("launch", map[string]interface{}{"program": program})
+
+	var breakpoints struct {
+		Breakpoints []struct {
+			Verified bool
+			Line     int
+		}
+	}
+	client.decode(client.request("setBreakpoints", map[string]interface{}{
+		"source":      map[string]interface{}{"path": program},
+		"breakpoints": []interface{}{map[string]interface{}{"line": 4}, map[string]interface{}{"line": 5}},
+	}), &breakpoints)
+	if len(breakpoints.Breakpoints) != 2 || !breakpoints.Breakpoints[0].Verified || breakpoints.Breakpoints[1].Verified {
+		t.Errorf("got breakpoints %+v, want line 4 verified and line 5, which holds no statement, not", breakpoints.Breakpoints)
+	}
+
+	client.request("configurationDone", nil)
+	var stopped struct{ Reason string }
+	client.decode(client.waitEvent("stopped"), &stopped)
+	if stopped.Reason != "breakpoint" {
+		t.Errorf("stopped for %q, want breakpoint", stopped.Reason)
+	}
+
+	var trace struct {
+		StackFrames []struct {
+			ID   int
+			Name string
+			Line int
+		}
+	}
+	client.decode(client.request("stackTrace", map[string]interface{}{"threadId": DAP_THREAD_ID}), &trace)
+	frames := []string{}
+	for _, frame := range trace.StackFrames {
+		frames = append(frames, frame.Name)
+	}
+	if want := []string{"block at line 2", "<script>"}; !reflect.DeepEqual(frames, want) || trace.StackFrames[0].Line != 4 {
+		t.Fatalf("got frames %+v, want %q stopped at line 4", trace.StackFrames, want)
+	}
+
+	var evaluated struct{ Result string }
+	client.decode(client.request("evaluate", map[string]interface{}{
+		"expression": "b * 10", "frameId": trace.StackFrames[0].ID,
+	}), &evaluated)
+	if evaluated.Result != "20" {
+		t.Errorf("b * 10 evaluated to %q, want 20", evaluated.Result)
+	}
+
+	client.request("continue", map[string]interface{}{"threadId": DAP_THREAD_ID})
+	var exited struct{ ExitCode int }
+	client.decode(client.waitEvent("exited"), &exited)
+	if exited.ExitCode != 0 {
+		t.Errorf("the program exited with %d, want 0", exited.ExitCode)
+	}
+	client.waitEvent("terminated")
+
+	if output, want := client.output(), "stdout: 2\nstdout: 1\n"; output != want {
+		t.Errorf("the program printed %q, want %q", output, want)
+	}
+
+	client.request("disconnect", nil)
+	if code := <-status; code != 0 {
+		t.Errorf("the adapter exited with %d, want 0", code)
+	}
+}
+
+func TestDebugAdapterRunsProgramsLikeRun(t *testing.T) {
+	dir := t.TempDir()
+	program := filepath.Join(dir, "program.lox")
+	source := "import lib from \"lib.lox\";\nvar unset;\nprint math.sqrt(16);\nprint lib.name;\n"
+	if err := os.WriteFile(program, []byte(source), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "lib.lox"), []byte("var name = \"lib\";\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	client, status := newDAPClient(t)
+
+	client.request("initialize", map[string]interface{}{"adapterID": "lox"})
+	client.request("launch", map[string]interface{}{"program": program})
+	client.request("setBreakpoints", map[string]interface{}{
+		"source":      map[string]interface{}{"path": program},
+		"breakpoints": []interface{}{map[string]interface{}{"line": 4}},
+	})
+	client.request("configurationDone", nil)
+	client.waitEvent("stopped")
+
+	var scopes struct {
+		Scopes []struct{ VariablesReference int }
+	}
+	client.decode(client.request("scopes", map[string]interface{}{"frameId": 0}), &scopes)
+	if len(scopes.Scopes) != 1 {
+		t.Fatalf("got scopes %+v, want only the globals", scopes.Scopes)
+	}
+	var variables struct {
+		Variables []struct{ Name, Value string }
+	}
+	client.decode(client.request("variables", map[string]interface{}{
+		"variablesReference": scopes.Scopes[0].VariablesReference,
+	}), &variables)
+	values := map[string]string{}
+	for _, variable := range variables.Variables {
+		values[variable.Name] = variable.Value
+	}
+	if values["unset"] != "nil" || !strings.HasSuffix(values["lib"], "lib.lox>") {
+		t.Errorf("got unset = %q and lib = %q, want nil and the module", values["unset"], values["lib"])
+	}
+
+	var evaluated struct{ Result string }
+	client.decode(client.request("evaluate", map[string]interface{}{"expression": "math.floor(lib.name.length / 2)"}), &evaluated)
+	if evaluated.Result != "1" {
+		t.Errorf("got %q, want 1", evaluated.Result)
+	}
+
+	client.request("continue", map[string]interface{}{"threadId": DAP_THREAD_ID})
+	client.waitEvent("terminated")
+	if output, want := client.output(), "stdout: 4\nstdout: lib\n"; output != want {
+		t.Errorf("the program printed %q, want %q", output, want)
+	}
+	client.request("disconnect", nil)
+	<-status
+}
@@ -0,0 +1,359 @@
+// debugger.go
+package main
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/codecrafters-io/interpreter-starter-go/lox"
+)
+
+// StepMode tells the debugger when to pause next
+type StepMode int
+
+const (
+	STEP_CONTINUE StepMode = iota // Only pause at breakpoints
+	STEP_INTO                     // Pause at the next statement
+	STEP_OVER                     // Pause at the next statement not nested deeper
+	STEP_OUT                      // Pause once the current block is left
+)
+
+// DebugFrame is a statement being executed and the environment it runs in.
+// Frame 0 is the top-level script, every block adds one frame.
+type DebugFrame struct {
+	Stmt lox.Stmt
+	Env  *lox.Environment
+	Line int
+}
+
+// Debugger pauses evaluation at statement boundaries. It only decides where
+// to stop; what happens while paused is up to OnPause, which returns how to
+// resume. The terminal debugger and the DAP server are both built on it.
+type Debugger struct {
+	Frames  []DebugFrame
+	OnPause func(reason string) StepMode
+
+	main           string // file of the program, whose statements are debugged
+	breakpoints    map[int]bool
+	breakpointsMu  sync.Mutex
+	mode           StepMode
+	stepDepth      int
+	started        bool
+	pauseRequested atomic.Bool
+}
+
+// NewDebugger creates a debugger, optionally stopping before the first
+// statement
+func NewDebugger(stopOnEntry bool) *Debugger {
+	d := &Debugger{breakpoints: map[int]bool{}, mode: STEP_CONTINUE}
+	if stopOnEntry {
+		d.mode = STEP_INTO
+	}
+	return d
+}
+
+// RequestPause asks the running program to stop at the next statement. It
+// may be called from another goroutine.
+func (d *Debugger) RequestPause() {
+	d.pauseRequested.Store(true)
+}
+
+// SetBreakpoint adds or removes a breakpoint on a line
+func (d *Debugger) SetBreakpoint(line int, enabled bool) {
+	d.breakpointsMu.Lock()
+	defer d.breakpointsMu.Unlock()
+	if enabled {
+		d.breakpoints[line] = true
+	} else {
+		delete(d.breakpoints, line)
+	}
+}
+
+// SetBreakpoints replaces every breakpoint
+func (d *Debugger) SetBreakpoints(lines []int) {
+	d.breakpointsMu.Lock()
+	defer d.breakpointsMu.Unlock()
+	d.breakpoints = map[int]bool{}
+	for _, line := range lines {
+		d.breakpoints[line] = true
+	}
+}
+
+// Breakpoints returns the lines with a breakpoint in order
+func (d *Debugger) Breakpoints() []int {
+	d.breakpointsMu.Lock()
+	defer d.breakpointsMu.Unlock()
+	lines := []int{}
+	for line := range d.breakpoints {
+		lines = append(lines, line)
+	}
+	sort.Ints(lines)
+	return lines
+}
+
+func (d *Debugger) hasBreakpoint(line int) bool {
+	d.breakpointsMu.Lock()
+	defer d.breakpointsMu.Unlock()
+	return d.breakpoints[line]
+}
+
+// Attach installs the debugger on an environment and its nested scopes.
+// Imported modules run without pausing: breakpoints, steps and frames are
+// all about the program's own lines.
+func (d *Debugger) Attach(env *lox.Environment) {
+	d.main = env.File()
+	env.Hooks.BeforeStmt = append(env.Hooks.BeforeStmt, d.beforeStmt)
+}
+
+// beforeStmt records the current frame and pauses if a breakpoint or the
+// step mode asks for it
+func (d *Debugger) beforeStmt(stmt lox.Stmt, env *lox.Environment) {
+	if env.File() != d.main {
+		return
+	}
+	depth := env.Depth()
+	line, _ := lox.LineRange(stmt)
+	if block, ok := stmt.(*lox.BlockStmt); ok {
+		line = block.Line
+	}
+	if depth > len(d.Frames) {
+		depth = len(d.Frames)
+	}
+	d.Frames = append(d.Frames[:depth], DebugFrame{Stmt: stmt, Env: env, Line: line})
+
+	reason := ""
+	switch {
+	case !d.started && d.mode == STEP_INTO:
+		reason = "entry"
+	case d.pauseRequested.Swap(false):
+		reason = "pause"
+	case d.hasBreakpoint(line):
+		reason = "breakpoint"
+	case d.mode == STEP_INTO:
+		reason = "step"
+	case d.mode == STEP_OVER && depth <= d.stepDepth:
+		reason = "step"
+	case d.mode == STEP_OUT && depth < d.stepDepth:
+		reason = "step"
+	}
+	d.started = true
+	if reason == "" {
+		return
+	}
+
+	d.mode = d.OnPause(reason)
+	d.stepDepth = depth
+}
+
+// CurrentFrame returns the innermost frame
+func (d *Debugger) CurrentFrame() DebugFrame {
+	return d.Frames[len(d.Frames)-1]
+}
+
+// Evaluate parses and evaluates an expression in the environment of a frame
+func (d *Debugger) Evaluate(source string, frame int) (interface{}, error) {
+	scanner := lox.NewLexer(source, false)
+	scanner.Scan()
+	if len(scanner.Errors()) > 0 {
+		return nil, scanner.Errors()[0]
+	}
+	expr, parseErr := lox.NewParser(scanner, "run").ParseExpression()
+	if parseErr != nil {
+		return nil, parseErr
+	}
+	return lox.Evaluate(expr, d.Frames[frame].Env)
+}
+
+// FrameName describes the code a frame belongs to
+func (d *Debugger) FrameName(frame int) string {
+	if frame == 0 {
+		return "<script>"
+	}
+	if block, ok := d.Frames[frame-1].Stmt.(*lox.BlockStmt); ok {
+		return fmt.Sprintf("block at line %d", block.Line)
+	}
+	return "block"
+}
+
+// terminalDebugger is the command-line front end of the debug command
+type terminalDebugger struct {
+	debugger *Debugger
+	lines    []string
+	in       *bufio.Scanner
+	out      io.Writer
+	selected int // frame used by print and locals
+}
+
+const debuggerHelp = `Commands:
+  break <line>, b      set a breakpoint
+  delete <line>, d     remove a breakpoint
+  breakpoints          list breakpoints
+  step, s              step into the next statement
+  next, n              step over nested blocks
+  finish, out, o       run until the current block is left
+  continue, c          run until the next breakpoint
+  print <expr>, p      evaluate an expression in the selected frame
+  locals               show the variables of every scope of the selected frame
+  backtrace, bt        show the frames
+  frame <n>, f         select a frame
+  list, l              show the source around the current line
+  quit, q              stop the program`
+
+// runDebugger runs a program under the terminal debugger, reading commands
+// from stdin, in the same interpreter as the run command but without
+// constant folding, so every statement can be stepped through as written.
+// It returns the exit status.
+func runDebugger(filename string, source string, options scriptOptions) int {
+	interpreter := lox.New(options.interpreterOptions(filename))
+	statements, err := interpreter.Parse(source)
+	if err != nil {
+		return lox.ExitCode(err)
+	}
+
+	t := &terminalDebugger{
+		debugger: NewDebugger(true),
+		lines:    strings.Split(source, "\n"),
+		in:       bufio.NewScanner(os.Stdin),
+		out:      os.Stdout,
+	}
+	t.debugger.OnPause = t.pause
+
+	t.debugger.Attach(interpreter.Globals)
+	if _, err := interpreter.Execute(context.Background(), statements); err != nil {
+		return lox.ExitCode(err)
+	}
+	fmt.Fprintln(t.out, "Program finished.")
+	return 0
+}
+
+// pause shows where execution stopped and reads commands until one resumes
+func (t *terminalDebugger) pause(reason string) StepMode {
+	t.selected = len(t.debugger.Frames) - 1
+	frame := t.debugger.CurrentFrame()
+	if reason == "breakpoint" {
+		fmt.Fprintf(t.out, "Breakpoint at line %d\n", frame.Line)
+	}
+	t.showLine(frame.Line, true)
+
+	for {
+		fmt.Fprint(t.out, "(lox) ")
+		if !t.in.Scan() {
+			fmt.Fprintln(t.out)
+			t.debugger.SetBreakpoints(nil)
+			return STEP_CONTINUE // No more input, run to completion
+		}
+
+		fields := strings.Fields(t.in.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		command, argument := fields[0], strings.TrimSpace(strings.TrimPrefix(t.in.Text(), fields[0]))
+		argument = strings.TrimSpace(argument)
+
+		switch command {
+		case "step", "s":
+			return STEP_INTO
+		case "next", "n":
+			return STEP_OVER
+		case "finish", "out", "o":
+			return STEP_OUT
+		case "continue", "c":
+			return STEP_CONTINUE
+		case "quit", "q":
+			os.Exit(0)
+		case "break", "b", "delete", "d":
+			line, err := strconv.Atoi(argument)
+			if err != nil {
+				fmt.Fprintf(t.out, "Expected a line number, got '%s'.\n", argument)
+				continue
+			}
+			if command == "break" || command == "b" {
+				t.debugger.SetBreakpoint(line, true)
+				fmt.Fprintf(t.out, "Breakpoint set at line %d.\n", line)
+			} else {
+				t.debugger.SetBreakpoint(line, false)
+				fmt.Fprintf(t.out, "Breakpoint removed from line %d.\n", line)
+			}
+		case "breakpoints":
+			for _, line := range t.debugger.Breakpoints() {
+				t.showLine(line, false)
+			}
+		case "print", "p":
+			value, err := t.debugger.Evaluate(argument, t.selected)
+			if err != nil {
+				fmt.Fprintf(t.out, "Error: %v\n", err)
+				continue
+			}
+			fmt.Fprintln(t.out, lox.Stringify(value))
+		case "locals":
+			t.showLocals()
+		case "backtrace", "bt":
+			for i := len(t.debugger.Frames) - 1; i >= 0; i-- {
+				marker := " "
+				if i == t.selected {
+					marker = "*"
+				}
+				fmt.Fprintf(t.out, "%s#%d line %d in %s\n", marker, i, t.debugger.Frames[i].Line, t.debugger.FrameName(i))
+			}
+		case "frame", "f":
+			frame, err := strconv.Atoi(argument)
+			if err != nil || frame < 0 || frame >= len(t.debugger.Frames) {
+				fmt.Fprintf(t.out, "No frame '%s'.\n", argument)
+				continue
+			}
+			t.selected = frame
+			t.showLine(t.debugger.Frames[frame].Line, true)
+		case "list", "l":
+			line := t.debugger.Frames[t.selected].Line
+			for i := line - 3; i <= line+3; i++ {
+				t.showLine(i, i == line)
+			}
+		case "help", "h":
+			fmt.Fprintln(t.out, debuggerHelp)
+		default:
+			fmt.Fprintf(t.out, "Unknown command '%s'. Type help for a list.\n", command)
+		}
+	}
+}
+
+// showLocals prints the variables of the selected frame scope by scope,
+// following the Parent chain up to the globals
+func (t *terminalDebugger) showLocals() {
+	env := t.debugger.Frames[t.selected].Env
+	for depth := env.Depth(); env != nil; env, depth = env.Parent, depth-1 {
+		scope := fmt.Sprintf("scope %d", depth)
+		if depth == 0 {
+			scope = "globals"
+		}
+		fmt.Fprintf(t.out, "%s:\n", scope)
+
+		names := []string{}
+		for name := range env.Values {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(t.out, "  %s = %s\n", name, lox.Stringify(env.Values[name]))
+		}
+	}
+}
+
+// showLine prints a numbered source line, marking the current one
+func (t *terminalDebugger) showLine(line int, current bool) {
+	if line < 1 || line > len(t.lines) {
+		return
+	}
+	marker := " "
+	if current {
+		marker = ">"
+	}
+	fmt.Fprintf(t.out, "%s %4d | %s\n", marker, line, strings.TrimRight(t.lines[line-1], "\r"))
+}
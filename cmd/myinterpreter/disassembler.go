@@ -0,0 +1,73 @@
+// disassembler.go
+package main
+
+import (
+	"fmt"
+	"io"
+)
+
+// DisassembleFunction prints the chunk of a compiled function followed by
+// every function nested in its constant pool
+func DisassembleFunction(w io.Writer, function *CompiledFunction) {
+	DisassembleChunk(w, function.Chunk, function.String())
+
+	for _, constant := range function.Chunk.Constants {
+		if nested, ok := constant.(*CompiledFunction); ok {
+			fmt.Fprintln(w)
+			DisassembleFunction(w, nested)
+		}
+	}
+}
+
+// DisassembleChunk prints every instruction of a chunk in the clox format
+func DisassembleChunk(w io.Writer, chunk *Chunk, name string) {
+	fmt.Fprintf(w, "== %s ==\n", name)
+
+	for offset := 0; offset < len(chunk.Code); {
+		offset = DisassembleInstruction(w, chunk, offset)
+	}
+}
+
+// DisassembleInstruction prints the instruction at offset and returns the
+// offset of the next one
+func DisassembleInstruction(w io.Writer, chunk *Chunk, offset int) int {
+	fmt.Fprintf(w, "%04d ", offset)
+	if offset > 0 && chunk.Lines[offset] == chunk.Lines[offset-1] {
+		fmt.Fprint(w, "   | ")
+	} else {
+		fmt.Fprintf(w, "%4d ", chunk.Lines[offset])
+	}
+
+	op := OpCode(chunk.Code[offset])
+	switch op {
+	case OP_CONSTANT, OP_GET_GLOBAL, OP_DEFINE_GLOBAL, OP_SET_GLOBAL, OP_GET_PROPERTY, OP_SET_PROPERTY,
+		OP_IMPORT:
+		return constantInstruction(w, op, chunk, offset)
+	case OP_GET_LOCAL, OP_SET_LOCAL, OP_CALL, OP_BUILD_LIST, OP_BUILD_MAP:
+		return byteInstruction(w, op, chunk, offset)
+	case OP_NIL, OP_TRUE, OP_FALSE, OP_POP, OP_EQUAL, OP_GREATER, OP_LESS,
+		OP_ADD, OP_SUBTRACT, OP_MULTIPLY, OP_DIVIDE, OP_NOT, OP_NEGATE,
+		OP_PRINT, OP_RETURN, OP_GET_INDEX, OP_SET_INDEX, OP_IMPORT_NAMES:
+		return simpleInstruction(w, op, offset)
+	default:
+		fmt.Fprintf(w, "Unknown opcode %d\n", op)
+		return offset + 1
+	}
+}
+
+func simpleInstruction(w io.Writer, op OpCode, offset int) int {
+	fmt.Fprintf(w, "%s\n", op)
+	return offset + 1
+}
+
+func byteInstruction(w io.Writer, op OpCode, chunk *Chunk, offset int) int {
+	slot := chunk.Code[offset+1]
+	fmt.Fprintf(w, "%-16s %4d\n", op, slot)
+	return offset + 2
+}
+
+func constantInstruction(w io.Writer, op OpCode, chunk *Chunk, offset int) int {
+	constant := chunk.Code[offset+1]
+	fmt.Fprintf(w, "%-16s %4d '%v'\n", op, constant, chunk.Constants[constant])
+	return offset + 2
+}
@@ -0,0 +1,262 @@
+// formatter.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/codecrafters-io/interpreter-starter-go/lox"
+)
+
+// Formatter reprints parsed statements in the canonical style: four space
+// indentation, one statement per line, spaces around binary operators and
+// the opening brace of a block on the line of the statement that owns it.
+// Comments are reattached by line number and at most one blank line between
+// statements is kept. A statement spread over several lines with comments
+// between them keeps its lines as written, so the comments stay next to the
+// code they describe.
+type Formatter struct {
+	out      strings.Builder
+	source   string
+	tokens   []lox.Token
+	comments []lox.Comment
+	next     int // index of the next comment to print
+	depth    int
+	lastLine int // last source line printed so far
+}
+
+// FormatSource parses a Lox program and returns it formatted
+func FormatSource(source string) string {
+	scanner := lox.NewLexer(source, false)
+	statements := mustParse(scanner, "run")
+
+	f := &Formatter{source: source, tokens: scanner.Tokens(), comments: scanner.Comments()}
+	f.formatStatements(statements, 0)
+	f.flushComments(0)
+	return f.out.String()
+}
+
+// formatStatements prints a list of statements at the current depth. end is
+// the line of the closing brace around them, 0 at the top level.
+func (f *Formatter) formatStatements(statements []lox.Stmt, end int) {
+	for i, stmt := range statements {
+		first, last := lox.LineRange(stmt)
+		f.flushComments(first)
+		f.blankLineBefore(first)
+
+		next := end
+		if i+1 < len(statements) {
+			next, _ = lox.LineRange(statements[i+1])
+		}
+		alone := f.lastLine < first && (next == 0 || next > last)
+
+		f.writeIndent()
+		if block, ok := stmt.(*lox.BlockStmt); ok {
+			f.formatBlock(block)
+		} else if alone && f.hasCommentBefore(last) {
+			f.writeSource(first, last)
+		} else {
+			f.out.WriteString(formatStatement(stmt))
+			f.lastLine = last
+			f.trailingComment()
+		}
+		f.out.WriteString("\n")
+	}
+}
+
+// writeSource prints a statement that has the lines from first to last to
+// itself as it is written, comments included, only reindenting its first
+// line
+func (f *Formatter) writeSource(first, last int) {
+	start, end := -1, 0
+	for _, token := range f.tokens {
+		if token.Line == first && start < 0 {
+			start = token.Offset
+		}
+		if token.Line == last {
+			end = token.Offset + len(token.Lexeme)
+		}
+	}
+	f.out.WriteString(f.source[start:end])
+	for f.hasCommentBefore(last) {
+		f.next++
+	}
+	f.lastLine = last
+	f.trailingComment()
+}
+
+// formatBlock prints a block, the caller having written the indentation
+func (f *Formatter) formatBlock(block *lox.BlockStmt) {
+	f.out.WriteString("{")
+	f.lastLine = block.Line
+	f.trailingComment()
+
+	if len(block.Statements) == 0 && !f.hasCommentBefore(block.EndLine) {
+		f.out.WriteString("}")
+	} else {
+		f.out.WriteString("\n")
+		f.depth++
+		f.formatStatements(block.Statements, block.EndLine)
+		f.flushComments(block.EndLine)
+		f.depth--
+		f.writeIndent()
+		f.out.WriteString("}")
+	}
+
+	f.lastLine = block.EndLine
+	f.trailingComment()
+}
+
+// formatStatement returns a non-block statement on a single line
+func formatStatement(stmt lox.Stmt) string {
+	switch s := stmt.(type) {
+	case *lox.PrintStatement:
+		return "print " + formatExpr(s.Expression) + ";"
+	case *lox.ExpressionStatement:
+		return formatExpr(s.Expression) + ";"
+	case *lox.VarStmt:
+		text := s.Name
+		if s.VarUsed {
+			text = "var " + text
+		}
+		if s.Initializer != nil {
+			text += " = " + formatExpr(s.Initializer)
+		}
+		return text + ";"
+	case *lox.ImportStmt:
+		if s.Name != "" {
+			return fmt.Sprintf("import %s from \"%s\";", s.Name, s.Path)
+		}
+		return fmt.Sprintf("import \"%s\";", s.Path)
+	}
+	return formatExpr(stmt) + ";"
+}
+
+// formatExpr returns an expression in canonical spacing
+func formatExpr(expr lox.Expr) string {
+	switch e := expr.(type) {
+	case *lox.Literal:
+		switch e.Type {
+		case "string":
+			return fmt.Sprintf("\"%s\"", e.Value)
+		case "number":
+			if e.Lexeme != "" {
+				return e.Lexeme
+			}
+		}
+		return e.String()
+	case *lox.Identifier:
+		return e.Name
+	case *lox.Grouping:
+		return "(" + formatExpr(e.Expression) + ")"
+	case *lox.Unary:
+		return e.Operator.Lexeme + formatExpr(e.Right)
+	case *lox.Binary:
+		return formatExpr(e.Left) + " " + e.Operator.Lexeme + " " + formatExpr(e.Right)
+	case *lox.AssignStmt:
+		return e.Name + " = " + formatExpr(e.Value)
+	case *lox.Call:
+		arguments := []string{}
+		for _, argument := range e.Arguments {
+			arguments = append(arguments, formatExpr(argument))
+		}
+		return formatExpr(e.Callee) + "(" + strings.Join(arguments, ", ") + ")"
+	case *lox.Get:
+		return formatExpr(e.Object) + "." + e.Name
+	case *lox.Set:
+		return formatExpr(e.Object) + "." + e.Name + " = " + formatExpr(e.Value)
+	case *lox.ListLiteral:
+		elements := []string{}
+		for _, element := range e.Elements {
+			elements = append(elements, formatExpr(element))
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case *lox.MapLiteral:
+		entries := []string{}
+		for i := range e.Keys {
+			entries = append(entries, formatExpr(e.Keys[i])+": "+formatExpr(e.Values[i]))
+		}
+		return "{" + strings.Join(entries, ", ") + "}"
+	case *lox.Index:
+		return formatExpr(e.Object) + "[" + formatExpr(e.Index) + "]"
+	case *lox.SetIndex:
+		return formatExpr(e.Object) + "[" + formatExpr(e.Index) + "] = " + formatExpr(e.Value)
+	}
+	return expr.String()
+}
+
+// flushComments prints every pending comment that starts before line, each
+// on its own line. A line of 0 prints all remaining comments.
+func (f *Formatter) flushComments(line int) {
+	for f.next < len(f.comments) && (line == 0 || f.comments[f.next].Line < line) {
+		comment := f.comments[f.next]
+		f.blankLineBefore(comment.Line)
+		f.writeIndent()
+		f.out.WriteString(comment.Text + "\n")
+		f.lastLine = comment.Line
+		f.next++
+	}
+}
+
+// trailingComment appends a comment that sits on the last printed line
+func (f *Formatter) trailingComment() {
+	if f.next < len(f.comments) && f.comments[f.next].Line == f.lastLine {
+		f.out.WriteString(" " + f.comments[f.next].Text)
+		f.next++
+	}
+}
+
+// hasCommentBefore checks for pending comments before the given line
+func (f *Formatter) hasCommentBefore(line int) bool {
+	return f.next < len(f.comments) && f.comments[f.next].Line < line
+}
+
+// blankLineBefore keeps a single blank line when the source had any between
+// the previous output and line, except at the start of a file or block
+func (f *Formatter) blankLineBefore(line int) {
+	output := f.out.String()
+	if f.lastLine == 0 || line <= f.lastLine+1 || strings.HasSuffix(output, "{\n") {
+		return
+	}
+	f.out.WriteString("\n")
+}
+
+func (f *Formatter) writeIndent() {
+	f.out.WriteString(strings.Repeat("    ", f.depth))
+}
+
+// runFmt implements the fmt command. Without flags the formatted source is
+// printed, -w rewrites the files in place and --check lists the files that
+// are not formatted. It returns the exit status.
+func runFmt(filenames []string, write, check bool) int {
+	status := 0
+	for _, filename := range filenames {
+		source, err := os.ReadFile(filename)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading file: %v\n", err)
+			status = 1
+			continue
+		}
+
+		formatted := FormatSource(string(source))
+		switch {
+		case check:
+			if formatted != string(source) {
+				fmt.Println(filename)
+				status = 1
+			}
+		case write:
+			if formatted == string(source) {
+				continue
+			}
+			if err := os.WriteFile(filename, []byte(formatted), 0644); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing file: %v\n", err)
+				status = 1
+			}
+		default:
+			fmt.Print(formatted)
+		}
+	}
+	return status
+}
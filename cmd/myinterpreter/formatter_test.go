@@ -0,0 +1,32 @@
+package main
+
+import "testing"
+
+func TestFormatKeepsCommentsInsideStatements(t *testing.T) {
+	tests := []struct {
+		source, want string
+	}{
+		{
+			"var total = 1 +\n  // the second operand\n  2; // done\nprint total;\n",
+			"var total = 1 +\n  // the second operand\n  2; // done\nprint total;\n",
+		},
+		{
+			"{\nprint 1 + // one\n        2;\n}\n",
+			"{\n    print 1 + // one\n        2;\n}\n",
+		},
+		// Without comments inside, a statement is joined on one line
+		{
+			"var total = 1 +\n  2; // done\n",
+			"var total = 1 + 2; // done\n",
+		},
+	}
+	for _, test := range tests {
+		got := FormatSource(test.source)
+		if got != test.want {
+			t.Errorf("formatting %q gave %q, want %q", test.source, got, test.want)
+		}
+		if again := FormatSource(got); again != got {
+			t.Errorf("formatting %q again gave %q", got, again)
+		}
+	}
+}
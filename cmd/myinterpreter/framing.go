@@ -0,0 +1,43 @@
+// framing.go
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/textproto"
+	"strconv"
+)
+
+// readFramedMessage reads one JSON message preceded by a Content-Length
+// header, the framing shared by the LSP and DAP servers
+func readFramedMessage(in *bufio.Reader, message interface{}) error {
+	headers, err := textproto.NewReader(in).ReadMIMEHeader()
+	if err != nil {
+		return err
+	}
+	length, err := strconv.Atoi(headers.Get("Content-Length"))
+	if err != nil {
+		return fmt.Errorf("invalid Content-Length header: %v", err)
+	}
+
+	body := make([]byte, length)
+	if _, err := io.ReadFull(in, body); err != nil {
+		return err
+	}
+	if err := json.Unmarshal(body, message); err != nil {
+		return fmt.Errorf("invalid message: %v", err)
+	}
+	return nil
+}
+
+// writeFramedMessage writes one JSON message with its Content-Length header
+func writeFramedMessage(out io.Writer, message interface{}) error {
+	body, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(out, "Content-Length: %d\r\n\r\n%s", len(body), body)
+	return err
+}
@@ -0,0 +1,329 @@
+// lint.go
+package main
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/codecrafters-io/interpreter-starter-go/lox"
+)
+
+// Lint rule ids, used in reports, --enable/--disable and lint:ignore comments
+const (
+	RULE_UNUSED_VARIABLE     = "unused-variable"
+	RULE_SHADOWING           = "shadowing"
+	RULE_SELF_ASSIGNMENT     = "self-assignment"
+	RULE_CONSTANT_COMPARISON = "constant-comparison"
+	RULE_UNREACHABLE_CODE    = "unreachable-code"
+)
+
+var LINT_RULES = []string{
+	RULE_UNUSED_VARIABLE,
+	RULE_SHADOWING,
+	RULE_SELF_ASSIGNMENT,
+	RULE_CONSTANT_COMPARISON,
+	RULE_UNREACHABLE_CODE,
+}
+
+// LintDiagnostic is a single problem found by the linter
+type LintDiagnostic struct {
+	Rule    string
+	Line    int
+	Message string
+}
+
+// linter collects diagnostics for the enabled rules
+type linter struct {
+	enabled     map[string]bool
+	diagnostics []LintDiagnostic
+	failure     *lox.RuntimeError // first runtime error the program always raises
+	unreachable bool              // whether the code after failure was reported
+}
+
+// Lint checks a program against the enabled rules. Diagnostics on a line with
+// a `// lint:ignore <rule>` comment, or on the line right after one, are
+// dropped. Several rules can be listed, separated by commas or spaces.
+func Lint(statements []lox.Stmt, comments []lox.Comment, enabled map[string]bool) []LintDiagnostic {
+	l := &linter{enabled: enabled}
+
+	resolver := Resolve(statements)
+	for _, declaration := range resolver.Declarations {
+		line := declaration.Line
+		if declaration.Reads == 0 {
+			l.report(RULE_UNUSED_VARIABLE, line, "Variable '%s' is declared but never used.", declaration.Name)
+		}
+		if declaration.Shadows != nil {
+			l.report(RULE_SHADOWING, line, "Variable '%s' shadows the variable declared on line %d.",
+				declaration.Name, declaration.Shadows.Line)
+		}
+	}
+	l.checkStatements(statements)
+
+	ignored := lintIgnores(comments)
+	diagnostics := []LintDiagnostic{}
+	for _, diagnostic := range l.diagnostics {
+		if !ignored[diagnostic.Line][diagnostic.Rule] && !ignored[diagnostic.Line]["all"] {
+			diagnostics = append(diagnostics, diagnostic)
+		}
+	}
+	sort.SliceStable(diagnostics, func(i, j int) bool {
+		return diagnostics[i].Line < diagnostics[j].Line
+	})
+	return diagnostics
+}
+
+// checkStatements checks a program or a block. Lox has no return or
+// exception handling, so the statements following one that always raises a
+// runtime error are unreachable: the first of them is reported.
+func (l *linter) checkStatements(statements []lox.Stmt) {
+	for i, stmt := range statements {
+		l.check(stmt)
+		if l.failure != nil && !l.unreachable && i+1 < len(statements) {
+			l.unreachable = true
+			line, _ := lox.LineRange(statements[i+1])
+			if block, ok := statements[i+1].(*lox.BlockStmt); ok {
+				line = block.Line
+			}
+			l.report(RULE_UNREACHABLE_CODE, line, "Code is unreachable: line %d always fails with '%s'.",
+				l.failure.Line, strings.TrimSuffix(l.failure.Message, "."))
+		}
+	}
+}
+
+// check applies the syntactic rules to a node and its children
+func (l *linter) check(node lox.Expr) {
+	if l.failure == nil && isConstantExpr(node) {
+		if _, err := lox.Evaluate(node, nil); err != nil {
+			l.failure = err.(*lox.RuntimeError)
+		}
+	}
+
+	switch n := node.(type) {
+	case *lox.BlockStmt:
+		l.checkStatements(n.Statements)
+	case *lox.VarStmt:
+		if n.Initializer == nil {
+			return
+		}
+		if identifier, ok := n.Initializer.(*lox.Identifier); ok && !n.VarUsed && identifier.Name == n.Name {
+			l.report(RULE_SELF_ASSIGNMENT, n.Line, "Variable '%s' is assigned to itself.", n.Name)
+		}
+		l.check(n.Initializer)
+	case *lox.AssignStmt:
+		if identifier, ok := n.Value.(*lox.Identifier); ok && identifier.Name == n.Name {
+			l.report(RULE_SELF_ASSIGNMENT, n.Line, "Variable '%s' is assigned to itself.", n.Name)
+		}
+		l.check(n.Value)
+	case *lox.PrintStatement:
+		l.check(n.Expression)
+	case *lox.ExpressionStatement:
+		l.check(n.Expression)
+	case *lox.Grouping:
+		l.check(n.Expression)
+	case *lox.Unary:
+		l.check(n.Right)
+	case *lox.Binary:
+		l.checkComparison(n)
+		l.check(n.Left)
+		l.check(n.Right)
+	case *lox.Call:
+		l.check(n.Callee)
+		for _, argument := range n.Arguments {
+			l.check(argument)
+		}
+	case *lox.Get:
+		l.check(n.Object)
+	case *lox.Set:
+		l.check(n.Object)
+		l.check(n.Value)
+	case *lox.ListLiteral:
+		for _, element := range n.Elements {
+			l.check(element)
+		}
+	case *lox.MapLiteral:
+		for i := range n.Keys {
+			l.check(n.Keys[i])
+			l.check(n.Values[i])
+		}
+	case *lox.Index:
+		l.check(n.Object)
+		l.check(n.Index)
+	case *lox.SetIndex:
+		l.check(n.Object)
+		l.check(n.Index)
+		l.check(n.Value)
+	}
+}
+
+// checkComparison reports comparisons whose result is known without running
+// the program: both sides are constants, whose comparison is evaluated, or
+// the same expression. A comparison raising a runtime error is left to the
+// unreachable code rule.
+func (l *linter) checkComparison(b *lox.Binary) {
+	var alwaysTrue bool
+	switch b.Operator.Type {
+	case "EQUAL_EQUAL", "GREATER_EQUAL", "LESS_EQUAL":
+		alwaysTrue = true
+	case "BANG_EQUAL", "GREATER", "LESS":
+		alwaysTrue = false
+	default:
+		return
+	}
+
+	if isConstantExpr(b) {
+		if value, err := lox.Evaluate(b, nil); err == nil {
+			l.report(RULE_CONSTANT_COMPARISON, b.Line, "Comparison '%s' is always %v.", formatExpr(b), value)
+		}
+	} else if sameExpr(b.Left, b.Right) {
+		l.report(RULE_CONSTANT_COMPARISON, b.Line, "Comparison '%s' is always %t.", formatExpr(b), alwaysTrue)
+	}
+}
+
+// isConstantExpr reports whether an expression only involves literals, so
+// evaluating it always gives the same value or the same runtime error
+func isConstantExpr(expr lox.Expr) bool {
+	switch e := expr.(type) {
+	case *lox.Literal:
+		return true
+	case *lox.Grouping:
+		return isConstantExpr(e.Expression)
+	case *lox.Unary:
+		return isConstantExpr(e.Right)
+	case *lox.Binary:
+		return isConstantExpr(e.Left) && isConstantExpr(e.Right)
+	case *lox.ListLiteral:
+		for _, element := range e.Elements {
+			if !isConstantExpr(element) {
+				return false
+			}
+		}
+		return true
+	case *lox.MapLiteral:
+		for i := range e.Keys {
+			if !isConstantExpr(e.Keys[i]) || !isConstantExpr(e.Values[i]) {
+				return false
+			}
+		}
+		return true
+	case *lox.Index:
+		return isConstantExpr(e.Object) && isConstantExpr(e.Index)
+	}
+	return false
+}
+
+func (l *linter) report(rule string, line int, format string, args ...interface{}) {
+	if l.enabled[rule] {
+		l.diagnostics = append(l.diagnostics, LintDiagnostic{Rule: rule, Line: line, Message: fmt.Sprintf(format, args...)})
+	}
+}
+
+// sameExpr reports whether two side effect free expressions are identical
+func sameExpr(a, b lox.Expr) bool {
+	switch x := a.(type) {
+	case *lox.Identifier:
+		y, ok := b.(*lox.Identifier)
+		return ok && x.Name == y.Name
+	case *lox.Literal:
+		y, ok := b.(*lox.Literal)
+		return ok && x.Type == y.Type && x.Value == y.Value
+	case *lox.Grouping:
+		y, ok := b.(*lox.Grouping)
+		return ok && sameExpr(x.Expression, y.Expression)
+	case *lox.Unary:
+		y, ok := b.(*lox.Unary)
+		return ok && x.Operator.Type == y.Operator.Type && sameExpr(x.Right, y.Right)
+	case *lox.Binary:
+		y, ok := b.(*lox.Binary)
+		return ok && x.Operator.Type == y.Operator.Type && sameExpr(x.Left, y.Left) && sameExpr(x.Right, y.Right)
+	}
+	return false
+}
+
+// lintIgnores maps every line to the rules suppressed on it
+func lintIgnores(comments []lox.Comment) map[int]map[string]bool {
+	ignored := map[int]map[string]bool{}
+	for _, comment := range comments {
+		text := strings.TrimSpace(strings.TrimPrefix(comment.Text, "//"))
+		if !strings.HasPrefix(text, "lint:ignore") {
+			continue
+		}
+		rules := strings.FieldsFunc(strings.TrimPrefix(text, "lint:ignore"), func(r rune) bool {
+			return r == ',' || r == ' ' || r == '\t'
+		})
+		if len(rules) == 0 {
+			rules = []string{"all"}
+		}
+		for _, line := range []int{comment.Line, comment.Line + 1} {
+			if ignored[line] == nil {
+				ignored[line] = map[string]bool{}
+			}
+			for _, rule := range rules {
+				ignored[line][rule] = true
+			}
+		}
+	}
+	return ignored
+}
+
+// lintRuleSet builds the set of enabled rules from the comma separated
+// --enable and --disable flags. An empty enable list means every rule.
+func lintRuleSet(enable, disable string) (map[string]bool, error) {
+	known := map[string]bool{}
+	for _, rule := range LINT_RULES {
+		known[rule] = true
+	}
+
+	enabled := map[string]bool{}
+	if enable == "" {
+		for _, rule := range LINT_RULES {
+			enabled[rule] = true
+		}
+	}
+	for _, rule := range splitList(enable) {
+		if !known[rule] {
+			return nil, fmt.Errorf("unknown lint rule '%s'", rule)
+		}
+		enabled[rule] = true
+	}
+	for _, rule := range splitList(disable) {
+		if !known[rule] {
+			return nil, fmt.Errorf("unknown lint rule '%s'", rule)
+		}
+		delete(enabled, rule)
+	}
+	return enabled, nil
+}
+
+// splitList splits a comma separated flag value
+func splitList(value string) []string {
+	items := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// runLint implements the lint command and returns the exit status
+func runLint(filename string, source string, enable, disable string) int {
+	enabled, err := lintRuleSet(enable, disable)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	scanner := lox.NewLexer(source, false)
+	statements := mustParse(scanner, "run")
+
+	diagnostics := Lint(statements, scanner.Comments(), enabled)
+	for _, diagnostic := range diagnostics {
+		fmt.Printf("%s:%d: %s: %s\n", filename, diagnostic.Line, diagnostic.Rule, diagnostic.Message)
+	}
+	if len(diagnostics) > 0 {
+		return 1
+	}
+	return 0
+}
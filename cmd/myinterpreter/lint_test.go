@@ -0,0 +1,103 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/codecrafters-io/interpreter-starter-go/lox"
+)
+
+// lint runs every rule over source and returns the diagnostics as
+// "line rule: message"
+func lint(t *testing.T, source string) []string {
+	t.Helper()
+	lexer := lox.NewLexer(source, false)
+	lexer.SetOutput(io.Discard, io.Discard)
+	if err := lexer.ScanTokens(); err != nil {
+		t.Fatalf("%s: %v", source, err)
+	}
+	statements, err := lox.NewParser(lexer, "run").Parse()
+	if err != nil {
+		t.Fatalf("%s: %v", source, err)
+	}
+	enabled, _ := lintRuleSet("", "")
+	reports := []string{}
+	for _, diagnostic := range Lint(statements, lexer.Comments(), enabled) {
+		reports = append(reports, fmt.Sprintf("%d %s: %s", diagnostic.Line, diagnostic.Rule, diagnostic.Message))
+	}
+	return reports
+}
+
+func TestLintConstantComparisons(t *testing.T) {
+	source := `var a = 1;
+print a == a;
+print a < a;
+print 1 < 2;
+print "a" == "b";
+print (1 + 2) >= 3;
+print a == 1;`
+	want := []string{
+		"2 constant-comparison: Comparison 'a == a' is always true.",
+		"3 constant-comparison: Comparison 'a < a' is always false.",
+		"4 constant-comparison: Comparison '1 < 2' is always true.",
+		`5 constant-comparison: Comparison '"a" == "b"' is always false.`,
+		"6 constant-comparison: Comparison '(1 + 2) >= 3' is always true.",
+	}
+	if got := lint(t, source); !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestLintUnreachableCode(t *testing.T) {
+	tests := []struct {
+		source string
+		want   []string
+	}{
+		{"print 1;\nprint -\"x\";\nprint 2;\nprint 3;", []string{
+			"3 unreachable-code: Code is unreachable: line 2 always fails with 'Operands must be a number'.",
+		}},
+		// Failing at the end of a block makes the code after the block unreachable
+		{"{\n  print 1 / 0;\n}\nprint 2;", []string{
+			"4 unreachable-code: Code is unreachable: line 2 always fails with 'Cannot divide by zero'.",
+		}},
+		{"print \"a\" < 1;\nprint 2;", []string{
+			"2 unreachable-code: Code is unreachable: line 1 always fails with 'Operands must be a number'.",
+		}},
+		{"print nil + 1; // lint:ignore unreachable-code\nprint 2;", []string{}},
+		{"print 1;\nprint 2;", []string{}},
+	}
+	for _, test := range tests {
+		if got := lint(t, test.source); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.source, got, test.want)
+		}
+	}
+}
+
+func TestLintAssignmentsInBlocksDefineVariables(t *testing.T) {
+	tests := []struct {
+		source string
+		want   []string
+	}{
+		// The block gets its own s; the global one is only read
+		{"var s = 0;\n{\n  s = s + 1;\n  print s;\n}\nprint s;", []string{
+			"3 shadowing: Variable 's' shadows the variable declared on line 1.",
+		}},
+		{"var s = 0;\n{\n  s = 1;\n}\nprint s;", []string{
+			"3 unused-variable: Variable 's' is declared but never used.",
+			"3 shadowing: Variable 's' shadows the variable declared on line 1.",
+		}},
+		// Assigning again in the same block writes to the block's variable
+		{"var s = 0;\n{\n  s = 1;\n  s = s + 1;\n  print s;\n}\nprint s;", []string{
+			"3 shadowing: Variable 's' shadows the variable declared on line 1.",
+		}},
+		{"var s = 0;\ns = s + 1;\nprint s;", []string{}},
+		{"var s = 0;\nprint s;\n{\n  print s;\n}", []string{}},
+	}
+	for _, test := range tests {
+		if got := lint(t, test.source); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: got %q, want %q", test.source, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,451 @@
+// lsp.go
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/codecrafters-io/interpreter-starter-go/lox"
+)
+
+// LSP symbol and completion kinds used by the server
+const (
+	LSP_SYMBOL_VARIABLE     = 13
+	LSP_COMPLETION_VARIABLE = 6
+	LSP_COMPLETION_KEYWORD  = 14
+	LSP_SEVERITY_ERROR      = 1
+)
+
+// LSP_KEYWORDS are the keywords completion offers: those the parser accepts.
+// The other reserved words have no syntax yet.
+var LSP_KEYWORDS = []string{"false", "from", "import", "nil", "print", "true", "var"}
+
+// lspRequest is an incoming JSON-RPC request or notification
+type lspRequest struct {
+	ID     *json.RawMessage `json:"id"`
+	Method string           `json:"method"`
+	Params json.RawMessage  `json:"params"`
+}
+
+type lspPosition struct {
+	Line      int `json:"line"`
+	Character int `json:"character"`
+}
+
+type lspRange struct {
+	Start lspPosition `json:"start"`
+	End   lspPosition `json:"end"`
+}
+
+type lspLocation struct {
+	URI   string   `json:"uri"`
+	Range lspRange `json:"range"`
+}
+
+type lspDiagnostic struct {
+	Range    lspRange `json:"range"`
+	Severity int      `json:"severity"`
+	Source   string   `json:"source"`
+	Message  string   `json:"message"`
+}
+
+type lspDocumentSymbol struct {
+	Name           string   `json:"name"`
+	Detail         string   `json:"detail"`
+	Kind           int      `json:"kind"`
+	Range          lspRange `json:"range"`
+	SelectionRange lspRange `json:"selectionRange"`
+}
+
+type lspCompletionItem struct {
+	Label  string `json:"label"`
+	Kind   int    `json:"kind"`
+	Detail string `json:"detail,omitempty"`
+}
+
+// lspTextDocumentPosition holds the params shared by hover, definition and
+// completion requests
+type lspTextDocumentPosition struct {
+	TextDocument struct {
+		URI string `json:"uri"`
+	} `json:"textDocument"`
+	Position lspPosition `json:"position"`
+}
+
+// lspDocument is an open file with the result of analysing it
+type lspDocument struct {
+	uri        string
+	lines      []string
+	statements []lox.Stmt
+	resolver   *Resolver
+	diagnostic []lspDiagnostic
+}
+
+// LanguageServer answers LSP requests for the open Lox documents. Lines and
+// columns from the lexer are 1-based and count characters, LSP positions are
+// 0-based and count UTF-16 code units.
+type LanguageServer struct {
+	in        *bufio.Reader
+	out       io.Writer
+	documents map[string]*lspDocument
+	shutdown  bool
+}
+
+// NewLanguageServer creates a server reading from in and writing to out
+func NewLanguageServer(in io.Reader, out io.Writer) *LanguageServer {
+	return &LanguageServer{
+		in:        bufio.NewReader(in),
+		out:       out,
+		documents: map[string]*lspDocument{},
+	}
+}
+
+// Serve handles messages until the client sends exit or closes the input. It
+// returns the exit status the process should use.
+func (s *LanguageServer) Serve() int {
+	for {
+		request, err := s.readMessage()
+		if err != nil {
+			if err != io.EOF {
+				fmt.Fprintf(os.Stderr, "lsp: %v\n", err)
+			}
+			return 1
+		}
+		if request.Method == "exit" {
+			if s.shutdown {
+				return 0
+			}
+			return 1
+		}
+
+		result, rpcErr := s.handle(request)
+		if request.ID == nil {
+			continue // Notifications get no response
+		}
+		response := jsonObject{{"jsonrpc", "2.0"}, {"id", request.ID}}
+		if rpcErr != nil {
+			response = append(response, jsonField{"error", rpcErr})
+		} else {
+			response = append(response, jsonField{"result", result})
+		}
+		s.writeMessage(response)
+	}
+}
+
+// handle dispatches a request and returns its result or a JSON-RPC error
+func (s *LanguageServer) handle(request *lspRequest) (interface{}, interface{}) {
+	switch request.Method {
+	case "initialize":
+		return jsonObject{
+			{"capabilities", jsonObject{
+				{"textDocumentSync", 1}, // Full document sync
+				{"hoverProvider", true},
+				{"definitionProvider", true},
+				{"documentSymbolProvider", true},
+				{"completionProvider", jsonObject{}},
+			}},
+			{"serverInfo", jsonObject{{"name", "lox"}}},
+		}, nil
+	case "shutdown":
+		s.shutdown = true
+		return nil, nil
+	case "textDocument/didOpen":
+		var params struct {
+			TextDocument struct {
+				URI  string `json:"uri"`
+				Text string `json:"text"`
+			} `json:"textDocument"`
+		}
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return nil, lspError(-32602, err.Error())
+		}
+		s.update(params.TextDocument.URI, params.TextDocument.Text)
+		return nil, nil
+	case "textDocument/didChange":
+		var params struct {
+			TextDocument struct {
+				URI string `json:"uri"`
+			} `json:"textDocument"`
+			ContentChanges []struct {
+				Text string `json:"text"`
+			} `json:"contentChanges"`
+		}
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return nil, lspError(-32602, err.Error())
+		}
+		if len(params.ContentChanges) > 0 {
+			s.update(params.TextDocument.URI, params.ContentChanges[len(params.ContentChanges)-1].Text)
+		}
+		return nil, nil
+	case "textDocument/didClose":
+		var params lspTextDocumentPosition
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return nil, lspError(-32602, err.Error())
+		}
+		delete(s.documents, params.TextDocument.URI)
+		s.publishDiagnostics(params.TextDocument.URI, []lspDiagnostic{})
+		return nil, nil
+	case "textDocument/hover", "textDocument/definition", "textDocument/documentSymbol", "textDocument/completion":
+		var params lspTextDocumentPosition
+		if err := json.Unmarshal(request.Params, &params); err != nil {
+			return nil, lspError(-32602, err.Error())
+		}
+		document, ok := s.documents[params.TextDocument.URI]
+		if !ok {
+			return nil, lspError(-32602, "unknown document "+params.TextDocument.URI)
+		}
+		line, column := params.Position.Line+1, document.column(params.Position)
+
+		switch request.Method {
+		case "textDocument/hover":
+			return document.hover(line, column), nil
+		case "textDocument/definition":
+			return document.definition(line, column), nil
+		case "textDocument/documentSymbol":
+			return document.symbols(), nil
+		default:
+			return document.completion(line), nil
+		}
+	}
+
+	if request.ID != nil {
+		return nil, lspError(-32601, "method not found: "+request.Method)
+	}
+	return nil, nil
+}
+
+// update re-analyses a document and publishes its diagnostics
+func (s *LanguageServer) update(uri, text string) {
+	document := &lspDocument{uri: uri, lines: strings.Split(text, "\n"), diagnostic: []lspDiagnostic{}}
+
+	scanner := lox.NewLexer(text, false)
+	scanner.SetOutput(io.Discard, io.Discard) // errors are published as diagnostics
+	scanner.Scan()
+	for _, lexError := range scanner.Errors() {
+		document.addDiagnostic(lexError.Line, lexError.Column, 1, lexError.Message)
+	}
+
+	parser := lox.NewParser(scanner, "run")
+	statements, parseErrors := parser.ParseWithErrors()
+	for _, parseError := range parseErrors {
+		document.addDiagnostic(parseError.Line, parseError.Column, utf8.RuneCountInString(parseError.Lexeme), parseError.Message)
+	}
+
+	document.statements = statements
+	document.resolver = Resolve(statements)
+	s.documents[uri] = document
+	s.publishDiagnostics(uri, document.diagnostic)
+}
+
+func (s *LanguageServer) publishDiagnostics(uri string, diagnostics []lspDiagnostic) {
+	s.writeMessage(jsonObject{
+		{"jsonrpc", "2.0"},
+		{"method", "textDocument/publishDiagnostics"},
+		{"params", jsonObject{{"uri", uri}, {"diagnostics", diagnostics}}},
+	})
+}
+
+// readMessage reads one request from the client
+func (s *LanguageServer) readMessage() (*lspRequest, error) {
+	request := &lspRequest{}
+	if err := readFramedMessage(s.in, request); err != nil {
+		return nil, err
+	}
+	return request, nil
+}
+
+// writeMessage sends a response or notification to the client
+func (s *LanguageServer) writeMessage(message interface{}) {
+	if err := writeFramedMessage(s.out, message); err != nil {
+		fmt.Fprintf(os.Stderr, "lsp: %v\n", err)
+	}
+}
+
+func lspError(code int, message string) jsonObject {
+	return jsonObject{{"code", code}, {"message", message}}
+}
+
+// rangeAt converts a 1-based line and column plus a length, all counted in
+// characters, into a range
+func (d *lspDocument) rangeAt(line, column, length int) lspRange {
+	if length < 1 {
+		length = 1
+	}
+	return lspRange{Start: d.position(line, column), End: d.position(line, column+length)}
+}
+
+// position converts a 1-based line and column into an LSP position, whose
+// character counts UTF-16 code units
+func (d *lspDocument) position(line, column int) lspPosition {
+	character := 0
+	for _, r := range d.line(line) {
+		if column <= 1 {
+			break
+		}
+		column--
+		character += utf16Length(r)
+	}
+	return lspPosition{Line: line - 1, Character: character + column - 1}
+}
+
+// column converts an LSP position into the 1-based column of the lexer
+func (d *lspDocument) column(position lspPosition) int {
+	column, character := 1, 0
+	for _, r := range d.line(position.Line + 1) {
+		if character >= position.Character {
+			return column
+		}
+		column++
+		character += utf16Length(r)
+	}
+	return column + position.Character - character
+}
+
+// line returns the text of a 1-based line, empty past the end
+func (d *lspDocument) line(line int) string {
+	if line < 1 || line > len(d.lines) {
+		return ""
+	}
+	return d.lines[line-1]
+}
+
+// utf16Length returns how many UTF-16 code units encode a character
+func utf16Length(r rune) int {
+	if r >= 0x10000 {
+		return 2
+	}
+	return 1
+}
+
+func (d *lspDocument) addDiagnostic(line, column, length int, message string) {
+	d.diagnostic = append(d.diagnostic, lspDiagnostic{
+		Range:    d.rangeAt(line, column, length),
+		Severity: LSP_SEVERITY_ERROR,
+		Source:   "lox",
+		Message:  message,
+	})
+}
+
+// declarationAt finds the declaration named at a position, either through a
+// variable use or the name in the var statement itself
+func (d *lspDocument) declarationAt(line, column int) *Declaration {
+	for identifier, declaration := range d.resolver.References {
+		if identifier.Line == line && column >= identifier.Column && column <= identifier.Column+utf8.RuneCountInString(identifier.Name) {
+			return declaration
+		}
+	}
+	for _, declaration := range d.resolver.Declarations {
+		if declaration.NameLine == line && column >= declaration.NameColumn &&
+			column <= declaration.NameColumn+utf8.RuneCountInString(declaration.Name) {
+			return declaration
+		}
+	}
+	return nil
+}
+
+// hover describes the variable under the cursor
+func (d *lspDocument) hover(line, column int) interface{} {
+	declaration := d.declarationAt(line, column)
+	if declaration == nil {
+		return nil
+	}
+
+	scope := "global"
+	if declaration.Depth > 0 {
+		scope = "local"
+	}
+	text := fmt.Sprintf("```lox\n%s\n```\n%s variable declared on line %d",
+		formatStatement(declaration.Stmt), scope, declaration.NameLine)
+	return jsonObject{{"contents", jsonObject{{"kind", "markdown"}, {"value", text}}}}
+}
+
+// definition returns the location of the statement declaring the variable
+func (d *lspDocument) definition(line, column int) interface{} {
+	declaration := d.declarationAt(line, column)
+	if declaration == nil {
+		return nil
+	}
+	return lspLocation{URI: d.uri, Range: d.nameRange(declaration)}
+}
+
+// nameRange is the range of the variable name in its declaration
+func (d *lspDocument) nameRange(declaration *Declaration) lspRange {
+	return d.rangeAt(declaration.NameLine, declaration.NameColumn, utf8.RuneCountInString(declaration.Name))
+}
+
+// symbols lists every variable declared in the document
+func (d *lspDocument) symbols() []lspDocumentSymbol {
+	symbols := []lspDocumentSymbol{}
+	for _, declaration := range d.resolver.Declarations {
+		stmt := declaration.Stmt
+		first, last := lox.LineRange(stmt)
+		if declaration.NameLine < first {
+			first = declaration.NameLine
+		}
+		end := d.position(last, utf8.RuneCountInString(strings.TrimRight(d.line(last), "\r"))+1)
+
+		symbols = append(symbols, lspDocumentSymbol{
+			Name:   declaration.Name,
+			Detail: formatStatement(stmt),
+			Kind:   LSP_SYMBOL_VARIABLE,
+			Range: lspRange{
+				Start: lspPosition{Line: first - 1},
+				End:   end,
+			},
+			SelectionRange: d.nameRange(declaration),
+		})
+	}
+	return symbols
+}
+
+// completion offers the variables in scope at a line and the keywords
+func (d *lspDocument) completion(line int) []lspCompletionItem {
+	visible := map[string]*Declaration{}
+	declarations := map[lox.Stmt]*Declaration{}
+	for _, declaration := range d.resolver.Declarations {
+		declarations[declaration.Stmt] = declaration
+	}
+	collectVisible(d.statements, line, declarations, visible)
+
+	items := []lspCompletionItem{}
+	for name, declaration := range visible {
+		items = append(items, lspCompletionItem{
+			Label:  name,
+			Kind:   LSP_COMPLETION_VARIABLE,
+			Detail: formatStatement(declaration.Stmt),
+		})
+	}
+	for _, keyword := range LSP_KEYWORDS {
+		items = append(items, lspCompletionItem{Label: keyword, Kind: LSP_COMPLETION_KEYWORD})
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if items[i].Kind != items[j].Kind {
+			return items[i].Kind < items[j].Kind
+		}
+		return items[i].Label < items[j].Label
+	})
+	return items
+}
+
+// collectVisible adds the declarations made before line in the statements,
+// descending into the blocks that contain the line
+func collectVisible(statements []lox.Stmt, line int, declarations map[lox.Stmt]*Declaration, visible map[string]*Declaration) {
+	for _, stmt := range statements {
+		switch s := stmt.(type) {
+		case *lox.VarStmt:
+			if declaration, ok := declarations[s]; ok && s.NameLine <= line {
+				visible[s.Name] = declaration
+			}
+		case *lox.BlockStmt:
+			if s.Line <= line && line <= s.EndLine {
+				collectVisible(s.Statements, line, declarations, visible)
+			}
+		}
+	}
+}
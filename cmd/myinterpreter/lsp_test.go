@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// lspMessage is a response or notification sent by the server
+type lspMessage struct {
+	ID     *int            `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+	Result json.RawMessage `json:"result"`
+	Error  json.RawMessage `json:"error"`
+}
+
+// serveScript frames messages as a client would, serves them and returns what
+// the server answered along with its exit status
+func serveScript(t *testing.T, serve func(in, out *bytes.Buffer) int, messages []interface{}) ([]lspMessage, int) {
+	t.Helper()
+	var in, out bytes.Buffer
+	for _, message := range messages {
+		if err := writeFramedMessage(&in, message); err != nil {
+			t.Fatal(err)
+		}
+	}
+	status := serve(&in, &out)
+
+	answers := []lspMessage{}
+	reader := bufio.NewReader(&out)
+	for reader.Buffered() > 0 || out.Len() > 0 {
+		var message lspMessage
+		if err := readFramedMessage(reader, &message); err != nil {
+			t.Fatalf("reading the output: %v", err)
+		}
+		answers = append(answers, message)
+	}
+	return answers, status
+}
+
+func lspRequestMessage(id int, method string, params interface{}) map[string]interface{} {
+	return map[string]interface{}{"jsonrpc": "2.0", "id": id, "method": method, "params": params}
+}
+
+func lspNotification(method string, params interface{}) map[string]interface{} {
+	return map[string]interface{}{"jsonrpc": "2.0", "method": method, "params": params}
+}
+
+func TestLanguageServerSession(t *testing.T) {
+	const uri = "file:///test.lox"
+	at := func(line, character int) map[string]interface{} {
+		return map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri},
+			"position":     map[string]interface{}{"line": line, "character": character},
+		}
+	}
+	document := map[string]interface{}{"uri": uri}
+	messages := []interface{}{
+		lspRequestMessage(1, "initialize", map[string]interface{}{}),
+		lspNotification("initialized", map[string]interface{}{}),
+		// 😀 takes two UTF-16 code units, so x starts at character 19
+		lspNotification("textDocument/didOpen", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri, "text": "var s = \"é😀\"; var x = s;\nprint x;"},
+		}),
+		lspRequestMessage(2, "textDocument/definition", at(1, 6)),
+		lspRequestMessage(3, "textDocument/hover", at(0, 19)),
+		lspRequestMessage(4, "textDocument/hover", at(0, 17)),
+		lspRequestMessage(5, "textDocument/completion", at(1, 0)),
+		lspNotification("textDocument/didChange", map[string]interface{}{
+			"textDocument":   document,
+			"contentChanges": []interface{}{map[string]interface{}{"text": "print \"😀\" + @;"}},
+		}),
+		lspRequestMessage(6, "textDocument/unknown", at(0, 0)),
+		lspRequestMessage(7, "shutdown", nil),
+		lspNotification("exit", nil),
+	}
+	answers, status := serveScript(t, func(in, out *bytes.Buffer) int {
+		return NewLanguageServer(in, out).Serve()
+	}, messages)
+	if status != 0 {
+		t.Errorf("exit status %d after shutdown, want 0", status)
+	}
+
+	results := map[int]lspMessage{}
+	diagnostics := []string{}
+	for _, answer := range answers {
+		if answer.ID != nil {
+			results[*answer.ID] = answer
+		} else if answer.Method == "textDocument/publishDiagnostics" {
+			diagnostics = append(diagnostics, string(answer.Params))
+		}
+	}
+
+	check := func(id int, want string) {
+		t.Helper()
+		var got, expected interface{}
+		json.Unmarshal(results[id].Result, &got)
+		if err := json.Unmarshal([]byte(want), &expected); err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(got, expected) {
+			t.Errorf("request %d: got %s, want %s", id, results[id].Result, want)
+		}
+	}
+	check(2, `{"uri": "file:///test.lox", "range": {"start": {"line": 0, "character": 19}, "end": {"line": 0, "character": 20}}}`)
+	check(4, `null`)
+	var hover struct {
+		Contents struct{ Value string }
+	}
+	json.Unmarshal(results[3].Result, &hover)
+	if want := "```lox\nvar x = s;\n```\nglobal variable declared on line 1"; hover.Contents.Value != want {
+		t.Errorf("hover gave %q, want %q", hover.Contents.Value, want)
+	}
+
+	var completion []lspCompletionItem
+	json.Unmarshal(results[5].Result, &completion)
+	labels := []string{}
+	for _, item := range completion {
+		labels = append(labels, item.Label)
+	}
+	if want := []string{"s", "x", "false", "from", "import", "nil", "print", "true", "var"}; !reflect.DeepEqual(labels, want) {
+		t.Errorf("completion offered %q, want %q", labels, want)
+	}
+
+	if results[6].Error == nil {
+		t.Errorf("an unknown method got %s, want an error", results[6].Result)
+	}
+
+	want := []string{
+		`{"uri":"file:///test.lox","diagnostics":[]}`,
+		`{"uri":"file:///test.lox","diagnostics":[{"range":{"start":{"line":0,"character":13},"end":{"line":0,"character":14}},"severity":1,"source":"lox","message":"Unexpected character: @"},{"range":{"start":{"line":0,"character":14},"end":{"line":0,"character":15}},"severity":1,"source":"lox","message":"Expected expression."}]}`,
+	}
+	if !reflect.DeepEqual(diagnostics, want) {
+		t.Errorf("got diagnostics\n%s\nwant\n%s", diagnostics, want)
+	}
+}
+
+func TestLanguageServerResolvesAssignmentsInBlocks(t *testing.T) {
+	const uri = "file:///block.lox"
+	at := func(line, character int) map[string]interface{} {
+		return map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri},
+			"position":     map[string]interface{}{"line": line, "character": character},
+		}
+	}
+	// The assignment defines a new s in the block, which print s reads
+	text := "var s = 0;\n{\n  s = s + 1;\n  print s;\n}\nprint s;"
+	messages := []interface{}{
+		lspRequestMessage(1, "initialize", map[string]interface{}{}),
+		lspNotification("textDocument/didOpen", map[string]interface{}{
+			"textDocument": map[string]interface{}{"uri": uri, "text": text},
+		}),
+		lspRequestMessage(2, "textDocument/definition", at(3, 8)),
+		lspRequestMessage(3, "textDocument/definition", at(2, 6)),
+		lspRequestMessage(4, "textDocument/definition", at(5, 6)),
+		lspRequestMessage(5, "textDocument/hover", at(3, 8)),
+		lspRequestMessage(6, "shutdown", nil),
+		lspNotification("exit", nil),
+	}
+	answers, _ := serveScript(t, func(in, out *bytes.Buffer) int {
+		return NewLanguageServer(in, out).Serve()
+	}, messages)
+	results := map[int]string{}
+	for _, answer := range answers {
+		if answer.ID != nil {
+			results[*answer.ID] = string(answer.Result)
+		}
+	}
+
+	location := func(line, character int) string {
+		return fmt.Sprintf(`{"uri":"%s","range":{"start":{"line":%d,"character":%d},"end":{"line":%d,"character":%d}}}`,
+			uri, line, character, line, character+1)
+	}
+	if want := location(2, 2); results[2] != want {
+		t.Errorf("print s in the block is defined at %s, want %s", results[2], want)
+	}
+	if want := location(0, 4); results[3] != want {
+		t.Errorf("s + 1 reads %s, want the global %s", results[3], want)
+	}
+	if want := location(0, 4); results[4] != want {
+		t.Errorf("print s after the block is defined at %s, want the global %s", results[4], want)
+	}
+	var hover struct {
+		Contents struct{ Value string }
+	}
+	json.Unmarshal([]byte(results[5]), &hover)
+	if want := "```lox\ns = s + 1;\n```\nlocal variable declared on line 3"; hover.Contents.Value != want {
+		t.Errorf("hover gave %q, want %q", hover.Contents.Value, want)
+	}
+}
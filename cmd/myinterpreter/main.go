@@ -2,18 +2,67 @@
 package main
 
 import (
+	"flag"
 	"fmt"
+	"io"
 	"os"
+
+	"github.com/codecrafters-io/interpreter-starter-go/lox"
 )
 
 func main() {
+	if len(os.Args) == 2 && os.Args[1] == "lsp" {
+		os.Exit(NewLanguageServer(os.Stdin, os.Stdout).Serve())
+	}
+	if len(os.Args) == 2 && os.Args[1] == "dap" {
+		os.Exit(NewDebugAdapter(os.Stdin, os.Stdout).Serve())
+	}
+
 	if len(os.Args) < 3 {
-		fmt.Fprintln(os.Stderr, "Usage: ./your_program.sh <command> <filename>")
+		fmt.Fprintln(os.Stderr, "Usage: ./your_program.sh <command> [flags] <filename>")
 		os.Exit(1)
 	}
 
 	command := os.Args[1]
-	filename := os.Args[2]
+	flags := flag.NewFlagSet(command, flag.ExitOnError)
+	optimized := flags.Bool("optimized", false, "parse: print the statements after constant folding")
+	format := flags.String("format", "text", "tokenize: text, json or ndjson; parse: text, json or dot")
+	write := flags.Bool("w", false, "fmt: write the result to the file instead of stdout")
+	check := flags.Bool("check", false, "fmt: list files that are not formatted and exit with 1")
+	enable := flags.String("enable", "", "lint: comma separated rules to run (default all)")
+	disable := flags.String("disable", "", "lint: comma separated rules to skip")
+	var script scriptOptions
+	flags.BoolVar(&script.trace, "trace", false, "run: log every statement and expression as it is evaluated")
+	flags.StringVar(&script.traceOut, "trace-out", "", "run: write the trace to a file instead of stderr")
+	flags.StringVar(&script.traceLines, "trace-lines", "", "run: only trace a line range such as 3-10")
+	flags.StringVar(&script.traceNodes, "trace-nodes", "", "run: only trace comma separated node kinds such as Binary")
+	flags.StringVar(&script.profile, "profile", "", "run: write a gzipped pprof profile to this file")
+	flags.IntVar(&script.profileTop, "profile-top", 10, "run: number of lines in the profile summary on stderr")
+	flags.StringVar(&script.coverage, "coverage", "", "run: merge line coverage into this LCOV file")
+	flags.DurationVar(&script.timeout, "timeout", 0, "run: stop the program after this long, such as 5s")
+	flags.IntVar(&script.limits.Steps, "max-steps", 0, "run, debug: stop after evaluating this many statements and expressions")
+	flags.IntVar(&script.limits.CallDepth, "max-call-depth", 0, "run, debug: report a stack overflow beyond this many nested calls")
+	flags.IntVar(&script.limits.StringBytes, "max-string-bytes", 0, "run, debug: limit the total bytes of strings built by concatenation")
+	flags.IntVar(&script.limits.Objects, "max-objects", 0, "run, debug: limit the number of objects created")
+	flags.IntVar(&script.limits.Nesting, "max-nesting", 0, fmt.Sprintf("run, debug: limit how deeply expressions and blocks nest, at most %d", lox.MaxNesting))
+	flags.Var((*listFlag)(&script.capabilities.Read), "allow-read", "run, debug: comma separated paths readFile may read")
+	flags.Var((*listFlag)(&script.capabilities.Write), "allow-write", "run, debug: comma separated paths writeFile may write")
+	flags.Var((*listFlag)(&script.capabilities.Env), "allow-env", "run, debug: comma separated environment variables getenv may read")
+	flags.BoolVar(&script.capabilities.Exec, "allow-exec", false, "run, debug: let exec run subprocesses")
+	flags.Var((*listFlag)(&script.importPath), "import-path", "run, debug: comma separated directories to find imported modules in")
+	args := parseCommandArgs(flags, os.Args[2:])
+	if len(args) < 1 {
+		fmt.Fprintln(os.Stderr, "Usage: ./your_program.sh <command> [flags] <filename>")
+		os.Exit(1)
+	}
+	filename := args[0]
+
+	if command == "fmt" {
+		os.Exit(runFmt(args, *write, *check))
+	}
+	if command == "test" {
+		os.Exit(runTests(args))
+	}
 
 	rawFileContent, err := os.ReadFile(filename)
 	if err != nil {
@@ -22,50 +71,126 @@ func main() {
 	}
 
 	logEnabled := false
-	if command == "tokenize" {
+	if command == "tokenize" && *format == "text" {
 		logEnabled = true
 	}
 
 	switch command {
 	case "tokenize":
-		scanner := NewLexer(string(rawFileContent), logEnabled)
-		scanner.ScanTokens()
+		scanner := lox.NewLexer(string(rawFileContent), logEnabled)
+		if *format != "text" {
+			scanner.SetOutput(os.Stdout, io.Discard) // Errors are part of the JSON
+		}
+		scanner.Scan()
+
+		switch *format {
+		case "json":
+			err = WriteTokensJSON(os.Stdout, scanner)
+		case "ndjson":
+			err = WriteTokensNDJSON(os.Stdout, scanner)
+		case "text":
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown format: %s\n", *format)
+			os.Exit(1)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing JSON: %v\n", err)
+			os.Exit(1)
+		}
+		if len(scanner.Errors()) > 0 {
+			os.Exit(65)
+		}
 		// for _, token := range scanner.tokens {
 		// 	fmt.Printf("%#v\n", token)
 		// }
 	case "parse":
-		scanner := NewLexer(string(rawFileContent), logEnabled)
-		scanner.ScanTokens() // Tokenize first
-		parser := NewParser(scanner, command)
-		statements := parser.Parse()  // Parse multiple statements
-		for _, stmt := range statements {
-			fmt.Println(stmt.String())  // Output each parsed statement
+		scanner := lox.NewLexer(string(rawFileContent), logEnabled)
+		statements := mustParse(scanner, command) // Tokenize first, then parse multiple statements
+		if *optimized {
+			statements = lox.Optimize(statements)
+		}
+		switch *format {
+		case "json":
+			if err := WriteASTJSON(os.Stdout, statements); err != nil {
+				fmt.Fprintf(os.Stderr, "Error writing JSON: %v\n", err)
+				os.Exit(1)
+			}
+		case "dot":
+			WriteASTDot(os.Stdout, statements)
+		case "text":
+			for _, stmt := range statements {
+				fmt.Println(stmt.String())  // Output each parsed statement
+			}
+		default:
+			fmt.Fprintf(os.Stderr, "Unknown format: %s\n", *format)
+			os.Exit(1)
 		}
 	case "evaluate":
-		scanner := NewLexer(string(rawFileContent), logEnabled)
-		scanner.ScanTokens() // Tokenize first
-		parser := NewParser(scanner, command)
-		statements := parser.Parse()  // Parse multiple statements
+		scanner := lox.NewLexer(string(rawFileContent), logEnabled)
+		statements := mustParse(scanner, command) // Tokenize first, then parse multiple statements
 
-		environment := NewEnvironment()
+		environment := lox.NewEnvironment()
 
+		defer exitOnRuntimeError()
 		for _, stmt := range statements {
-			result := stmt.Eval(environment)  // Evaluate each statement
+			result := lox.ExecuteStatement(stmt, environment)  // Evaluate each statement
 			fmt.Println(result)     // Print the evaluation result
 		}
 	case "run":
-		scanner := NewLexer(string(rawFileContent), false)
-		scanner.ScanTokens()
-		parser := NewParser(scanner, command)
-		statements := parser.Parse()  // Parse the input
-
-		environment := NewEnvironment()
+		os.Exit(runScript(filename, string(rawFileContent), script))
+	case "debug":
+		os.Exit(runDebugger(filename, string(rawFileContent), script))
+	case "lint":
+		os.Exit(runLint(filename, string(rawFileContent), *enable, *disable))
+	case "disassemble":
+		scanner := lox.NewLexer(string(rawFileContent), false)
+		statements := mustParse(scanner, "run") // Statements need their semicolons like in run mode
 
-		for _, stmt := range statements {
-			stmt.Eval(environment)  // Evaluate each statement
-		}
+		compiler := NewCompiler()
+		DisassembleFunction(os.Stdout, compiler.Compile(statements))
 	default:
 		fmt.Fprintf(os.Stderr, "Unknown command: %s\n", command)
 		os.Exit(1)
 	}
-}
\ No newline at end of file
+}
+
+// mustParse scans and parses a program, exiting with status 65 once a
+// lexical or syntax error has been reported
+func mustParse(scanner *lox.Lexer, mode string) []lox.Stmt {
+	if err := scanner.ScanTokens(); err != nil {
+		os.Exit(65)
+	}
+	statements, err := lox.NewParser(scanner, mode).Parse()
+	if err != nil {
+		os.Exit(65)
+	}
+	return statements
+}
+
+// parseCommandArgs parses the flags of a command and returns the remaining
+// positional arguments. Flags may come before or after the filename.
+func parseCommandArgs(flags *flag.FlagSet, args []string) []string {
+	positional := []string{}
+	for {
+		flags.Parse(args)
+		args = flags.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// exitOnRuntimeError is deferred by commands that evaluate code. It writes a
+// runtime error to stderr and exits with its status.
+func exitOnRuntimeError() {
+	if r := recover(); r != nil {
+		err, ok := r.(*lox.RuntimeError)
+		if !ok {
+			panic(r)
+		}
+		fmt.Fprint(os.Stderr, err.Report())
+		os.Exit(err.ExitCode)
+	}
+}
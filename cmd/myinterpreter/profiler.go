@@ -0,0 +1,326 @@
+// profiler.go
+package main
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-io/interpreter-starter-go/lox"
+)
+
+// Profiler measures how long every statement takes and how often it runs.
+// Lox has no user functions yet, so the top-level script and every block
+// act as the functions of the profile: a statement inside nested blocks is
+// sampled with the stack script -> block -> block -> statement line. The
+// result is written in the pprof protobuf format so `go tool pprof` can
+// show it, and summarized by line in text form. The top level of an
+// imported module is the function "module" of its file, called by the
+// import statement.
+type Profiler struct {
+	filename string
+	main     string // absolute path of filename, to tell modules apart
+	start    time.Time
+	open     []*profileCall // statements being executed, outermost first
+	samples  map[string]*profileSample
+	order    []string // sample keys in first seen order
+	lines    map[sourceLine]*profileLine
+}
+
+// profileCall is a statement that started but has not finished yet
+type profileCall struct {
+	stmt     lox.Stmt
+	file     string
+	line     int
+	function string // function the statement belongs to
+	start    time.Time
+	children time.Duration // time spent in nested statements
+}
+
+// profileSample aggregates the executions of one statement with one stack
+type profileSample struct {
+	stack []profileFrame // leaf first, as pprof expects
+	count int64
+	self  time.Duration
+}
+
+type profileFrame struct {
+	file     string
+	function string
+	line     int
+}
+
+type sourceLine struct {
+	file string
+	line int
+}
+
+// profileLine is the summary of one source line
+type profileLine struct {
+	sourceLine
+	count int64
+	flat  time.Duration
+	cum   time.Duration
+}
+
+// NewProfiler creates a profiler for a program read from filename
+func NewProfiler(filename string) *Profiler {
+	return &Profiler{
+		filename: filename,
+		start:    time.Now(),
+		samples:  map[string]*profileSample{},
+		lines:    map[sourceLine]*profileLine{},
+	}
+}
+
+// Attach installs the profiler on an environment, its nested scopes and the
+// modules it imports
+func (p *Profiler) Attach(env *lox.Environment) {
+	p.main = env.File()
+	env.Hooks.BeforeStmt = append(env.Hooks.BeforeStmt, p.beforeStmt)
+	env.Hooks.AfterStmt = append(env.Hooks.AfterStmt, p.afterStmt)
+}
+
+func (p *Profiler) beforeStmt(stmt lox.Stmt, env *lox.Environment) {
+	line, _ := lox.LineRange(stmt)
+	if block, ok := stmt.(*lox.BlockStmt); ok {
+		line = block.Line
+	}
+	file := p.filename
+	function := "script" // pprof would strip "<script>" like C++ template arguments
+	if path := env.File(); path != p.main {
+		file = lox.DisplayPath(path)
+		function = "module"
+	}
+	for i := len(p.open) - 1; i >= 0 && p.open[i].file == file; i-- {
+		if block, ok := p.open[i].stmt.(*lox.BlockStmt); ok {
+			function = fmt.Sprintf("block at line %d", block.Line)
+			break
+		}
+	}
+	p.open = append(p.open, &profileCall{stmt: stmt, file: file, line: line, function: function, start: time.Now()})
+}
+
+func (p *Profiler) afterStmt(stmt lox.Stmt, env *lox.Environment, value interface{}) {
+	call := p.open[len(p.open)-1]
+	p.open = p.open[:len(p.open)-1]
+	elapsed := time.Since(call.start)
+	if len(p.open) > 0 {
+		p.open[len(p.open)-1].children += elapsed
+	}
+
+	stack := []profileFrame{{call.file, call.function, call.line}}
+	for i := len(p.open) - 1; i >= 0; i-- {
+		stack = append(stack, profileFrame{p.open[i].file, p.open[i].function, p.open[i].line})
+	}
+	keys := []string{}
+	for _, frame := range stack {
+		keys = append(keys, fmt.Sprintf("%s:%s:%d", frame.file, frame.function, frame.line))
+	}
+	key := strings.Join(keys, ";")
+	sample, ok := p.samples[key]
+	if !ok {
+		sample = &profileSample{stack: stack}
+		p.samples[key] = sample
+		p.order = append(p.order, key)
+	}
+	sample.count++
+	sample.self += elapsed - call.children
+
+	source := sourceLine{call.file, call.line}
+	summary, ok := p.lines[source]
+	if !ok {
+		summary = &profileLine{sourceLine: source}
+		p.lines[source] = summary
+	}
+	summary.count++
+	summary.flat += elapsed - call.children
+	summary.cum += elapsed
+}
+
+// WriteSummary writes the n lines with the most time spent in them
+func (p *Profiler) WriteSummary(w io.Writer, n int) {
+	lines := []*profileLine{}
+	var total time.Duration
+	var executed int64
+	for _, line := range p.lines {
+		lines = append(lines, line)
+		total += line.flat
+		executed += line.count
+	}
+	sort.Slice(lines, func(i, j int) bool {
+		if lines[i].flat != lines[j].flat {
+			return lines[i].flat > lines[j].flat
+		}
+		if lines[i].file != lines[j].file {
+			return lines[i].file < lines[j].file
+		}
+		return lines[i].line < lines[j].line
+	})
+	if n > 0 && len(lines) > n {
+		lines = lines[:n]
+	}
+
+	fmt.Fprintf(w, "Profile: %d statements executed in %v\n", executed, total)
+	fmt.Fprintf(w, "%12s %7s %12s %10s  %s\n", "flat", "flat%", "cum", "count", "line")
+	for _, line := range lines {
+		percent := 0.0
+		if total > 0 {
+			percent = 100 * float64(line.flat) / float64(total)
+		}
+		fmt.Fprintf(w, "%12v %6.2f%% %12v %10d  %s:%d\n", line.flat, percent, line.cum, line.count, line.file, line.line)
+	}
+}
+
+// WriteProfile writes the samples as a gzipped pprof profile. Every sample
+// has two values: how many times the statement ran and the time spent in it
+// excluding nested statements.
+func (p *Profiler) WriteProfile(w io.Writer) error {
+	table := []string{""}
+	stringIndex := map[string]int64{"": 0}
+	str := func(s string) int64 {
+		if index, ok := stringIndex[s]; ok {
+			return index
+		}
+		table = append(table, s)
+		stringIndex[s] = int64(len(table) - 1)
+		return stringIndex[s]
+	}
+
+	profile := &protoBuffer{}
+	valueType := func(field int, typ, unit string) {
+		message := &protoBuffer{}
+		message.int64Field(1, str(typ))
+		message.int64Field(2, str(unit))
+		profile.messageField(field, message)
+	}
+	valueType(1, "count", "count")
+	valueType(1, "time", "nanoseconds")
+
+	functions := map[profileFrame]uint64{} // keyed without the line
+	functionMessages := &protoBuffer{}
+	locations := map[profileFrame]uint64{}
+	locationMessages := &protoBuffer{}
+	for _, key := range p.order {
+		sample := p.samples[key]
+		ids := []uint64{}
+		for _, frame := range sample.stack {
+			functionID, ok := functions[profileFrame{file: frame.file, function: frame.function}]
+			if !ok {
+				functionID = uint64(len(functions) + 1)
+				functions[profileFrame{file: frame.file, function: frame.function}] = functionID
+				function := &protoBuffer{}
+				function.uint64Field(1, functionID)
+				function.int64Field(2, str(frame.function))
+				function.int64Field(3, str(frame.function))
+				function.int64Field(4, str(frame.file))
+				functionMessages.messageField(5, function)
+			}
+
+			locationID, ok := locations[frame]
+			if !ok {
+				locationID = uint64(len(locations) + 1)
+				locations[frame] = locationID
+				line := &protoBuffer{}
+				line.uint64Field(1, functionID)
+				line.int64Field(2, int64(frame.line))
+				location := &protoBuffer{}
+				location.uint64Field(1, locationID)
+				location.messageField(4, line)
+				locationMessages.messageField(4, location)
+			}
+			ids = append(ids, locationID)
+		}
+
+		message := &protoBuffer{}
+		message.packedUint64Field(1, ids)
+		message.packedInt64Field(2, []int64{sample.count, int64(sample.self)})
+		profile.messageField(2, message)
+	}
+	profile.bytes = append(profile.bytes, locationMessages.bytes...)
+	profile.bytes = append(profile.bytes, functionMessages.bytes...)
+
+	profile.int64Field(9, p.start.UnixNano())
+	profile.int64Field(10, int64(time.Since(p.start)))
+	valueType(11, "time", "nanoseconds")
+	profile.int64Field(12, 1)
+	// Written last, once every string has been added
+	for _, s := range table {
+		profile.stringField(6, s)
+	}
+
+	gz := gzip.NewWriter(w)
+	if _, err := gz.Write(profile.bytes); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+// protoBuffer encodes protocol buffer messages, just enough of the wire
+// format for the pprof profile
+type protoBuffer struct {
+	bytes []byte
+}
+
+func (b *protoBuffer) varint(x uint64) {
+	for x >= 0x80 {
+		b.bytes = append(b.bytes, byte(x)|0x80)
+		x >>= 7
+	}
+	b.bytes = append(b.bytes, byte(x))
+}
+
+func (b *protoBuffer) key(field int, wireType int) {
+	b.varint(uint64(field)<<3 | uint64(wireType))
+}
+
+func (b *protoBuffer) uint64Field(field int, x uint64) {
+	if x == 0 {
+		return
+	}
+	b.key(field, 0)
+	b.varint(x)
+}
+
+func (b *protoBuffer) int64Field(field int, x int64) {
+	if x == 0 {
+		return
+	}
+	b.key(field, 0)
+	b.varint(uint64(x))
+}
+
+func (b *protoBuffer) lengthDelimited(field int, data []byte) {
+	b.key(field, 2)
+	b.varint(uint64(len(data)))
+	b.bytes = append(b.bytes, data...)
+}
+
+// stringField always writes the string, even when empty, since the pprof
+// string table must start with ""
+func (b *protoBuffer) stringField(field int, s string) {
+	b.lengthDelimited(field, []byte(s))
+}
+
+func (b *protoBuffer) messageField(field int, message *protoBuffer) {
+	b.lengthDelimited(field, message.bytes)
+}
+
+func (b *protoBuffer) packedUint64Field(field int, xs []uint64) {
+	packed := &protoBuffer{}
+	for _, x := range xs {
+		packed.varint(x)
+	}
+	b.lengthDelimited(field, packed.bytes)
+}
+
+func (b *protoBuffer) packedInt64Field(field int, xs []int64) {
+	packed := &protoBuffer{}
+	for _, x := range xs {
+		packed.varint(uint64(x))
+	}
+	b.lengthDelimited(field, packed.bytes)
+}
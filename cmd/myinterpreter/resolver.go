@@ -0,0 +1,145 @@
+// resolver.go
+package main
+
+import "github.com/codecrafters-io/interpreter-starter-go/lox"
+
+// Declaration is a variable introduced by a `var` statement, or by an
+// assignment to a name the current scope does not have yet, which the
+// evaluator defines in that scope
+type Declaration struct {
+	Name       string
+	Stmt       lox.Stmt // the *lox.VarStmt or *lox.AssignStmt
+	Line       int      // line of the statement
+	NameLine   int      // position of the name in the statement
+	NameColumn int
+	Depth      int          // 0 for globals, one more for every enclosing block
+	Reads      int          // number of identifiers that read the variable
+	Writes     int          // number of assignments to the variable
+	Shadows    *Declaration // variable of an enclosing scope hidden by this one
+}
+
+// Resolver walks the statements mirroring the Environment scoping of the
+// evaluator, and links every variable use to its declaration
+type Resolver struct {
+	scopes       []map[string]*Declaration
+	Declarations []*Declaration
+	References   map[*lox.Identifier]*Declaration
+	Unresolved   []*lox.Identifier
+}
+
+// Resolve analyses a program
+func Resolve(statements []lox.Stmt) *Resolver {
+	r := &Resolver{
+		scopes:     []map[string]*Declaration{{}},
+		References: map[*lox.Identifier]*Declaration{},
+	}
+	r.resolveStatements(statements)
+	return r
+}
+
+func (r *Resolver) resolveStatements(statements []lox.Stmt) {
+	for _, stmt := range statements {
+		r.resolve(stmt)
+	}
+}
+
+// resolve handles a single statement or expression
+func (r *Resolver) resolve(node lox.Expr) {
+	switch n := node.(type) {
+	case *lox.BlockStmt:
+		r.scopes = append(r.scopes, map[string]*Declaration{})
+		r.resolveStatements(n.Statements)
+		r.scopes = r.scopes[:len(r.scopes)-1]
+	case *lox.VarStmt:
+		if n.Initializer != nil {
+			r.resolve(n.Initializer)
+		}
+		declaration := &Declaration{Name: n.Name, Stmt: n, Line: n.Line, NameLine: n.NameLine, NameColumn: n.NameColumn}
+		if n.VarUsed {
+			r.declare(declaration)
+		} else if r.lookup(n.Name) != nil {
+			r.assign(declaration) // Otherwise it fails, unless it replaces a native
+		}
+	case *lox.AssignStmt:
+		r.resolve(n.Value)
+		r.assign(&Declaration{Name: n.Name, Stmt: n, Line: n.Line, NameLine: n.NameLine, NameColumn: n.NameColumn})
+	case *lox.Identifier:
+		if declaration := r.lookup(n.Name); declaration != nil {
+			declaration.Reads++
+			r.References[n] = declaration
+		} else {
+			r.Unresolved = append(r.Unresolved, n)
+		}
+	case *lox.PrintStatement:
+		r.resolve(n.Expression)
+	case *lox.ExpressionStatement:
+		r.resolve(n.Expression)
+	case *lox.Grouping:
+		r.resolve(n.Expression)
+	case *lox.Unary:
+		r.resolve(n.Right)
+	case *lox.Binary:
+		r.resolve(n.Left)
+		r.resolve(n.Right)
+	case *lox.Call:
+		r.resolve(n.Callee)
+		for _, argument := range n.Arguments {
+			r.resolve(argument)
+		}
+	case *lox.Get:
+		r.resolve(n.Object)
+	case *lox.Set:
+		r.resolve(n.Object)
+		r.resolve(n.Value)
+	case *lox.ListLiteral:
+		for _, element := range n.Elements {
+			r.resolve(element)
+		}
+	case *lox.MapLiteral:
+		for i := range n.Keys {
+			r.resolve(n.Keys[i])
+			r.resolve(n.Values[i])
+		}
+	case *lox.Index:
+		r.resolve(n.Object)
+		r.resolve(n.Index)
+	case *lox.SetIndex:
+		r.resolve(n.Object)
+		r.resolve(n.Index)
+		r.resolve(n.Value)
+	}
+}
+
+// declare adds a variable to the innermost scope
+func (r *Resolver) declare(declaration *Declaration) {
+	declaration.Depth = len(r.scopes) - 1
+	for i := len(r.scopes) - 2; i >= 0; i-- {
+		if outer, ok := r.scopes[i][declaration.Name]; ok {
+			declaration.Shadows = outer
+			break
+		}
+	}
+	r.scopes[len(r.scopes)-1][declaration.Name] = declaration
+	r.Declarations = append(r.Declarations, declaration)
+}
+
+// assign writes to the variable of the innermost scope, or declares it there
+// when the scope does not have it: like Environment.Define, an assignment
+// inside a block never changes a variable of an enclosing scope
+func (r *Resolver) assign(declaration *Declaration) {
+	if existing, ok := r.scopes[len(r.scopes)-1][declaration.Name]; ok {
+		existing.Writes++
+		return
+	}
+	r.declare(declaration)
+}
+
+// lookup finds the declaration a name refers to, innermost scope first
+func (r *Resolver) lookup(name string) *Declaration {
+	for i := len(r.scopes) - 1; i >= 0; i-- {
+		if declaration, ok := r.scopes[i][name]; ok {
+			return declaration
+		}
+	}
+	return nil
+}
@@ -0,0 +1,150 @@
+// run.go
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/codecrafters-io/interpreter-starter-go/lox"
+)
+
+// scriptOptions are the flags of the run command
+type scriptOptions struct {
+	trace        bool
+	traceOut     string
+	traceLines   string
+	traceNodes   string
+	profile      string
+	profileTop   int
+	coverage     string
+	timeout      time.Duration
+	limits       lox.Limits
+	capabilities lox.Capabilities
+	importPath   []string
+}
+
+// listFlag is a flag holding a comma separated list, which may be repeated:
+// --allow-read=./data,./config --allow-read=/tmp
+type listFlag []string
+
+func (l *listFlag) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *listFlag) Set(value string) error {
+	for _, item := range strings.Split(value, ",") {
+		if item != "" {
+			*l = append(*l, item)
+		}
+	}
+	return nil
+}
+
+// runScript implements the run command on top of lox.Interpreter, with the
+// tracer, profiler and coverage counter attached as requested. The program
+// is cancelled by Ctrl-C and, with --timeout, after the given time, and
+// runs within the --max-* limits and --allow-* capabilities. It returns the
+// exit status.
+func runScript(filename string, source string, options scriptOptions) int {
+	interpreterOptions := options.interpreterOptions(filename)
+	interpreterOptions.Optimize = true
+	interpreter := lox.New(interpreterOptions)
+	statements, err := interpreter.Parse(source)
+	if err != nil {
+		return lox.ExitCode(err)
+	}
+
+	if options.trace {
+		var traceWriter io.Writer = os.Stderr
+		if options.traceOut != "" {
+			file, err := os.Create(options.traceOut)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error creating trace file: %v\n", err)
+				return 1
+			}
+			defer file.Close()
+			traceWriter = file
+		}
+		tracer, err := NewTracer(traceWriter, options.traceLines, options.traceNodes)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			return 1
+		}
+		tracer.Attach(interpreter.Globals)
+	}
+
+	var profiler *Profiler
+	if options.profile != "" {
+		profiler = NewProfiler(filename)
+		profiler.Attach(interpreter.Globals)
+	}
+
+	var counter *Coverage
+	if options.coverage != "" {
+		path, err := filepath.Abs(filename)
+		if err != nil {
+			path = filename
+		}
+		counter = NewCoverage(path, statements)
+		counter.Attach(interpreter.Globals)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if options.timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, options.timeout)
+		defer cancel()
+	}
+	_, err = interpreter.Execute(ctx, statements)
+
+	// Written even when the program failed, after the error report, which
+	// may not end its last line
+	var runtimeErr *lox.RuntimeError
+	if (profiler != nil || counter != nil) && errors.As(err, &runtimeErr) && !strings.HasSuffix(runtimeErr.Report(), "\n") {
+		fmt.Fprintln(os.Stderr)
+	}
+	if profiler != nil {
+		profiler.WriteSummary(os.Stderr, options.profileTop)
+		if err := writeProfile(options.profile, profiler); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing profile: %v\n", err)
+		}
+	}
+	if counter != nil {
+		if err := writeCoverage(options.coverage, counter.Records); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing coverage: %v\n", err)
+		}
+	}
+	return lox.ExitCode(err)
+}
+
+// interpreterOptions returns the options of the interpreter a program read
+// from filename runs in. The run, debug and dap commands share them, so a
+// program has the same natives, capabilities and modules under a debugger.
+func (o scriptOptions) interpreterOptions(filename string) lox.Options {
+	return lox.Options{
+		Limits:       o.limits,
+		Capabilities: o.capabilities,
+		Filename:     filename,
+		SearchPath:   o.importPath,
+	}
+}
+
+func writeProfile(filename string, profiler *Profiler) error {
+	file, err := os.Create(filename)
+	if err != nil {
+		return err
+	}
+	if err := profiler.WriteProfile(file); err != nil {
+		file.Close()
+		return err
+	}
+	return file.Close()
+}
@@ -0,0 +1,223 @@
+// test_runner.go
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Annotations understood by the test command, following the Crafting
+// Interpreters test suite:
+//
+//	print 1; // expect: 1                 a line of stdout
+//	-"a";    // expect runtime error: msg stderr "msg\n[line N]", exit 70
+//	// [line 3] Error at 'x': msg         a line of stderr, exit 65
+//	1 +;     // Error at ';': msg         same, on the comment's line
+//	// expect exit: 1                     overrides the expected exit code
+//	// flags: --max-steps=10             passed to run before the file
+var (
+	expectOutputPattern       = regexp.MustCompile(`// expect: ?(.*)$`)
+	expectRuntimeErrorPattern = regexp.MustCompile(`// expect runtime error: (.+)$`)
+	expectErrorPattern        = regexp.MustCompile(`// (\[line (\d+)\] )?(Error.*)$`)
+	expectExitPattern         = regexp.MustCompile(`// expect exit: (\d+)$`)
+	flagsPattern              = regexp.MustCompile(`// flags: (.+)$`)
+)
+
+// testExpectation is what running a test file should produce
+type testExpectation struct {
+	stdout   []string
+	stderr   []string
+	exitCode int
+	flags    []string
+}
+
+// parseExpectations reads the annotations of a test file
+func parseExpectations(source string) testExpectation {
+	expected := testExpectation{stdout: []string{}, stderr: []string{}}
+	exitCode := -1
+	for i, line := range strings.Split(source, "\n") {
+		line = strings.TrimRight(line, "\r")
+		number := i + 1
+		if match := expectOutputPattern.FindStringSubmatch(line); match != nil {
+			expected.stdout = append(expected.stdout, match[1])
+		} else if match := expectRuntimeErrorPattern.FindStringSubmatch(line); match != nil {
+			expected.stderr = append(expected.stderr, match[1], fmt.Sprintf("[line %d]", number))
+			expected.exitCode = 70
+		} else if match := expectErrorPattern.FindStringSubmatch(line); match != nil {
+			if match[2] != "" {
+				number, _ = strconv.Atoi(match[2])
+			}
+			expected.stderr = append(expected.stderr, fmt.Sprintf("[line %d] %s", number, match[3]))
+			expected.exitCode = 65
+		} else if match := expectExitPattern.FindStringSubmatch(line); match != nil {
+			exitCode, _ = strconv.Atoi(match[1])
+		} else if match := flagsPattern.FindStringSubmatch(line); match != nil {
+			expected.flags = append(expected.flags, strings.Fields(match[1])...)
+		}
+	}
+	if exitCode >= 0 {
+		expected.exitCode = exitCode
+	}
+	return expected
+}
+
+// runTestFile runs a test file with the run command of this executable and
+// returns the differences from its annotations
+func runTestFile(executable string, path string) ([]string, error) {
+	source, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	expected := parseExpectations(string(source))
+
+	var stdout, stderr bytes.Buffer
+	args := append(append([]string{"run"}, expected.flags...), path)
+	cmd := exec.Command(executable, args...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	exitCode := 0
+	if err := cmd.Run(); err != nil {
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) {
+			return nil, err
+		}
+		exitCode = exitErr.ExitCode()
+	}
+
+	failures := []string{}
+	if diff := diffLines(expected.stdout, outputLines(stdout.String())); diff != "" {
+		failures = append(failures, "stdout differs:\n"+diff)
+	}
+	if diff := diffLines(expected.stderr, outputLines(stderr.String())); diff != "" {
+		failures = append(failures, "stderr differs:\n"+diff)
+	}
+	if exitCode != expected.exitCode {
+		failures = append(failures, fmt.Sprintf("expected exit code %d, got %d", expected.exitCode, exitCode))
+	}
+	return failures, nil
+}
+
+// outputLines splits program output into lines, ignoring whether the last
+// one ends with a newline
+func outputLines(output string) []string {
+	output = strings.TrimRight(strings.ReplaceAll(output, "\r\n", "\n"), "\n")
+	if output == "" {
+		return []string{}
+	}
+	return strings.Split(output, "\n")
+}
+
+// diffLines returns a line diff of expected and actual output, with "-" for
+// missing and "+" for unexpected lines, or "" if they are equal
+func diffLines(expected, actual []string) string {
+	// lengths[i][j] is the longest common subsequence of expected[i:] and actual[j:]
+	lengths := make([][]int, len(expected)+1)
+	for i := range lengths {
+		lengths[i] = make([]int, len(actual)+1)
+	}
+	for i := len(expected) - 1; i >= 0; i-- {
+		for j := len(actual) - 1; j >= 0; j-- {
+			if expected[i] == actual[j] {
+				lengths[i][j] = lengths[i+1][j+1] + 1
+			} else if lengths[i+1][j] >= lengths[i][j+1] {
+				lengths[i][j] = lengths[i+1][j]
+			} else {
+				lengths[i][j] = lengths[i][j+1]
+			}
+		}
+	}
+
+	var diff strings.Builder
+	changed := false
+	i, j := 0, 0
+	for i < len(expected) || j < len(actual) {
+		switch {
+		case i < len(expected) && j < len(actual) && expected[i] == actual[j]:
+			fmt.Fprintf(&diff, "    %s\n", expected[i])
+			i++
+			j++
+		case j == len(actual) || (i < len(expected) && lengths[i+1][j] >= lengths[i][j+1]):
+			fmt.Fprintf(&diff, "  - %s\n", expected[i])
+			changed = true
+			i++
+		default:
+			fmt.Fprintf(&diff, "  + %s\n", actual[j])
+			changed = true
+			j++
+		}
+	}
+	if !changed {
+		return ""
+	}
+	return diff.String()
+}
+
+// collectTestFiles returns the .lox files under the given files and
+// directories in order
+func collectTestFiles(paths []string) ([]string, error) {
+	files := []string{}
+	for _, path := range paths {
+		err := filepath.WalkDir(path, func(file string, entry fs.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !entry.IsDir() && filepath.Ext(file) == ".lox" {
+				files = append(files, file)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(files)
+	return files, nil
+}
+
+// runTests implements the test command: it runs every .lox file found under
+// paths, prints a pass/fail report and returns the exit status
+func runTests(paths []string) int {
+	executable, err := os.Executable()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+	files, err := collectTestFiles(paths)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		return 1
+	}
+
+	passed, failed := 0, 0
+	for _, file := range files {
+		failures, err := runTestFile(executable, file)
+		if err != nil {
+			failures = []string{err.Error()}
+		}
+		if len(failures) == 0 {
+			passed++
+			fmt.Printf("PASS %s\n", file)
+			continue
+		}
+		failed++
+		fmt.Printf("FAIL %s\n", file)
+		for _, failure := range failures {
+			fmt.Printf("  %s\n", strings.TrimRight(failure, "\n"))
+		}
+	}
+
+	fmt.Printf("\n%d passed, %d failed\n", passed, failed)
+	if failed > 0 || passed == 0 {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,122 @@
+// tokens_json.go
+package main
+
+/*
+Machine-readable output of the tokenize command.
+
+`tokenize --format=json` writes a single document:
+
+	{"tokens": [<token>, ...], "errors": [<error>, ...]}
+
+`tokenize --format=ndjson` writes one entry per line, tokens and errors
+interleaved in source order, each tagged with "kind":
+
+	{"kind": "token", "type": "NUMBER", "lexeme": "1", "literal": 1, "line": 1, "column": 9}
+	{"kind": "error", "message": "Unexpected character: $", "line": 2, "column": 1}
+
+Token literals are numbers for NUMBER, strings for STRING and null otherwise.
+Lines and columns are 1-based and give where a token starts. Lines are those
+of the text output and error messages, where a newline inside a string does
+not count; columns count characters, not bytes, from the last newline, even
+one inside a string. The token list always ends with EOF. Lexical errors are
+only reported in the JSON, not on stderr.
+*/
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/codecrafters-io/interpreter-starter-go/lox"
+)
+
+// WriteTokensJSON writes the scanned tokens and errors as one JSON document
+func WriteTokensJSON(w io.Writer, l *lox.Lexer) error {
+	tokens := []interface{}{}
+	for _, token := range append(l.Tokens(), l.EOF()) {
+		tokens = append(tokens, tokenEntry(token, false))
+	}
+	errors := []interface{}{}
+	for _, lexError := range l.Errors() {
+		errors = append(errors, lexErrorEntry(lexError, false))
+	}
+
+	encoded, err := json.MarshalIndent(jsonObject{
+		{"tokens", tokens},
+		{"errors", errors},
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "%s\n", encoded)
+	return err
+}
+
+// WriteTokensNDJSON writes every token and error as its own JSON line
+func WriteTokensNDJSON(w io.Writer, l *lox.Lexer) error {
+	type entry struct {
+		offset int
+		value  jsonObject
+	}
+
+	entries := []entry{}
+	for _, token := range l.Tokens() {
+		entries = append(entries, entry{token.Offset, tokenEntry(token, true)})
+	}
+	for _, lexError := range l.Errors() {
+		entries = append(entries, entry{lexError.Offset, lexErrorEntry(lexError, true)})
+	}
+	sort.SliceStable(entries, func(i, j int) bool {
+		return entries[i].offset < entries[j].offset
+	})
+	eof := l.EOF()
+	entries = append(entries, entry{eof.Offset, tokenEntry(eof, true)})
+
+	for _, e := range entries {
+		encoded, err := json.Marshal(e.value)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "%s\n", encoded); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// tokenEntry converts a token, tagging it with its kind for NDJSON
+func tokenEntry(token lox.Token, tagged bool) jsonObject {
+	var literal interface{}
+	switch token.Type {
+	case "NUMBER":
+		literal, _ = lox.ConvertStringToFloat(token.Literal, token.Line)
+	case "STRING":
+		literal = token.Literal
+	}
+
+	entry := jsonObject{}
+	if tagged {
+		entry = append(entry, jsonField{"kind", "token"})
+	}
+	return append(entry,
+		jsonField{"type", token.Type},
+		jsonField{"lexeme", token.Lexeme},
+		jsonField{"literal", literal},
+		jsonField{"line", token.Line},
+		jsonField{"column", token.Column},
+	)
+}
+
+// lexErrorEntry converts a lexical error, tagging it with its kind for NDJSON
+func lexErrorEntry(lexError lox.LexError, tagged bool) jsonObject {
+	entry := jsonObject{}
+	if tagged {
+		entry = append(entry, jsonField{"kind", "error"})
+	}
+	return append(entry,
+		jsonField{"message", lexError.Message},
+		jsonField{"line", lexError.Line},
+		jsonField{"column", lexError.Column},
+	)
+}
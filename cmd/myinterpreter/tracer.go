@@ -0,0 +1,101 @@
+// tracer.go
+package main
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/codecrafters-io/interpreter-starter-go/lox"
+)
+
+// Tracer logs every statement and expression once it has been evaluated,
+// so the output follows the evaluation order: operands come before the
+// operator that uses them and a block is logged after its statements. Each
+// entry shows the source line, with its file when it belongs to an imported
+// module, the nesting depth of the environment, the node kind, the node as
+// printed by String() and the resulting value.
+type Tracer struct {
+	out       io.Writer
+	firstLine int // 0 means no lower bound
+	lastLine  int // 0 means no upper bound
+	kinds     map[string]bool
+	main      string // file of the program, whose lines are shown without it
+}
+
+// NewTracer creates a tracer writing to out. lines limits the trace to a line
+// range such as "3-10", "3-" or "7", and kinds to a comma separated list of
+// node kinds such as "Binary,PrintStatement". Empty filters trace everything.
+func NewTracer(out io.Writer, lines string, kinds string) (*Tracer, error) {
+	t := &Tracer{out: out}
+
+	if lines != "" {
+		first, last, isRange := strings.Cut(lines, "-")
+		var err error
+		if t.firstLine, err = strconv.Atoi(strings.TrimSpace(first)); err != nil {
+			return nil, fmt.Errorf("invalid line range '%s'", lines)
+		}
+		t.lastLine = t.firstLine
+		if isRange {
+			t.lastLine = 0
+			if last = strings.TrimSpace(last); last != "" {
+				if t.lastLine, err = strconv.Atoi(last); err != nil {
+					return nil, fmt.Errorf("invalid line range '%s'", lines)
+				}
+			}
+		}
+	}
+
+	if kinds != "" {
+		t.kinds = map[string]bool{}
+		for _, kind := range splitList(kinds) {
+			t.kinds[kind] = true
+		}
+	}
+	return t, nil
+}
+
+// Attach installs the tracer on an environment, its nested scopes and the
+// modules it imports
+func (t *Tracer) Attach(env *lox.Environment) {
+	t.main = env.File()
+	env.Hooks.AfterStmt = append(env.Hooks.AfterStmt, func(stmt lox.Stmt, env *lox.Environment, value interface{}) {
+		t.trace(stmt, env, value)
+	})
+	env.Hooks.AfterExpr = append(env.Hooks.AfterExpr, t.trace)
+}
+
+func (t *Tracer) trace(node lox.Expr, env *lox.Environment, value interface{}) {
+	line, _ := lox.LineRange(node)
+	if block, ok := node.(*lox.BlockStmt); ok {
+		line = block.Line
+	}
+	kind := lox.NodeKind(node)
+	if !t.matches(line, kind) {
+		return
+	}
+
+	text := node.String()
+	if block, ok := node.(*lox.BlockStmt); ok {
+		text = fmt.Sprintf("{ %d statements }", len(block.Statements))
+	}
+	if value == nil {
+		value = "nil"
+	}
+	location := fmt.Sprintf("line %d", line)
+	if file := env.File(); file != t.main {
+		location = fmt.Sprintf("%s:%d", lox.DisplayPath(file), line)
+	}
+	fmt.Fprintf(t.out, "[%s] depth %d %s %s => %v\n", location, env.Depth(), kind, text, value)
+}
+
+func (t *Tracer) matches(line int, kind string) bool {
+	if t.firstLine != 0 && line < t.firstLine {
+		return false
+	}
+	if t.lastLine != 0 && line > t.lastLine {
+		return false
+	}
+	return t.kinds == nil || t.kinds[kind]
+}
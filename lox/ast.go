@@ -0,0 +1,369 @@
+// ast.go
+package lox
+
+import (
+	"fmt"
+	"strings"
+)
+
+// ExprEvaluator is an interface for expressions that can be evaluated
+type ExprEvaluator interface {
+	Eval(env *Environment) interface{} // Method to evaluate the expression
+}
+
+// Expr interface for all expression nodes, extended to include ExprEvaluator
+type Expr interface {
+	String() string
+	ExprEvaluator // Include evaluation in the expression interface
+}
+
+// Literal struct for literal values (booleans, numbers, strings, nil)
+type Literal struct {
+	Value  interface{}
+	Type   string
+	Line   int
+	Lexeme string // Source text of number literals, e.g. "1.50"
+}
+
+// String method for Literal to print its content
+func (l *Literal) String() string {
+	if l.Value == nil {
+		return "nil"
+	}
+	return fmt.Sprintf("%v", l.Value)
+}
+
+// Grouping struct to represent expressions inside parentheses
+type Grouping struct {
+	Expression Expr
+	Line       int
+}
+
+func (g *Grouping) String() string {
+	return fmt.Sprintf("(group %s)", g.Expression.String())
+}
+
+// Unary struct for unary operators
+type Unary struct {
+	Operator Token
+	Right    Expr
+	Line 	 int
+}
+
+func (u *Unary) String() string {
+	return fmt.Sprintf("(%s %s)", u.Operator.Lexeme, u.Right.String())
+}
+
+// Binary struct to represent binary expressions (e.g., 16 * 38)
+type Binary struct {
+	Left     Expr
+	Operator Token
+	Right    Expr
+	Line	 int
+}
+
+func (b *Binary) String() string {
+	return fmt.Sprintf("(%s %s %s)", b.Operator.Lexeme, b.Left.String(), b.Right.String())
+}
+
+
+// Stmt interface for statements
+type Stmt interface {
+	Expr // Method to evaluate the statement
+}
+
+// ExpressionStatement wraps an expression as a statement
+type ExpressionStatement struct {
+	Expression Expr
+	Line       int
+}
+
+// String method for ExpressionStatement
+func (e *ExpressionStatement) String() string {
+	return e.Expression.String() // Return string representation of the expression
+}
+
+type PrintStatement struct {
+	Expression Expr
+	Line       int
+}
+
+// String method for PrintStatement
+func (p *PrintStatement) String() string {
+	return fmt.Sprintf("(print %s)", p.Expression.String()) // Return string representation of print statement
+}
+
+
+// VarStmt represents a variable declaration statement
+type VarStmt struct {
+	Name        string
+	Initializer Expr
+	VarUsed		bool
+	Line 		int
+	NameLine    int // Position of the variable name
+	NameColumn  int
+}
+
+func (v *VarStmt) String() string {
+	return fmt.Sprintf("var %s = %v", v.Name, v.Initializer)
+}
+
+// Identifier represents a variable being used in an expression
+type Identifier struct {
+	Name   string
+	Line   int
+	Column int
+}
+
+func (i *Identifier) String() string {
+	return i.Name
+}
+
+type AssignStmt struct {
+	Name  string
+	Value Expr
+	Line  int
+	NameLine   int // Position of the variable name
+	NameColumn int
+}
+
+func (a *AssignStmt) String() string {
+	return fmt.Sprintf("(%s = %s)", a.Name, a.Value.String())
+}
+
+type BlockStmt struct {
+	Statements []Stmt
+	Line       int
+	EndLine    int
+}
+
+func (b *BlockStmt) String() string {
+	val := fmt.Sprintf("{\n")
+	for _, statement := range b.Statements {
+		val += fmt.Sprintf("%s\n", statement.String())
+	}
+	val += fmt.Sprint("}")
+	return val
+}
+
+// Call is a call of a function value, such as a native bound by the host
+type Call struct {
+	Callee    Expr
+	Arguments []Expr
+	Line      int // Line of the closing parenthesis, where call errors are reported
+}
+
+func (c *Call) String() string {
+	val := fmt.Sprintf("(call %s", c.Callee.String())
+	for _, argument := range c.Arguments {
+		val += " " + argument.String()
+	}
+	return val + ")"
+}
+
+// Get reads a property of an object, such as a field or method of a Go value
+type Get struct {
+	Object Expr
+	Name   string
+	Line   int
+}
+
+func (g *Get) String() string {
+	return fmt.Sprintf("(. %s %s)", g.Object.String(), g.Name)
+}
+
+// Set assigns a property of an object
+type Set struct {
+	Object Expr
+	Name   string
+	Value  Expr
+	Line   int
+}
+
+func (s *Set) String() string {
+	return fmt.Sprintf("(%s.%s = %s)", s.Object.String(), s.Name, s.Value.String())
+}
+
+// ListLiteral creates a list from its elements, as in [1, 2, 3]
+type ListLiteral struct {
+	Elements []Expr
+	Line     int // Line of the closing bracket
+}
+
+func (l *ListLiteral) String() string {
+	val := "(list"
+	for _, element := range l.Elements {
+		val += " " + element.String()
+	}
+	return val + ")"
+}
+
+// MapLiteral creates a map from its entries, as in {"a": 1, "b": 2}. A
+// brace starts a map where an expression is expected and a block where a
+// statement is.
+type MapLiteral struct {
+	Keys   []Expr
+	Values []Expr
+	Line   int // Line of the closing brace
+}
+
+func (m *MapLiteral) String() string {
+	val := "(map"
+	for i := range m.Keys {
+		val += " " + m.Keys[i].String() + " " + m.Values[i].String()
+	}
+	return val + ")"
+}
+
+// Index reads an element of a list or the value of a map key, as in xs[i]
+type Index struct {
+	Object Expr
+	Index  Expr
+	Line   int
+}
+
+func (i *Index) String() string {
+	return fmt.Sprintf("(index %s %s)", i.Object.String(), i.Index.String())
+}
+
+// SetIndex assigns an element of a list or a map key, as in xs[i] = v
+type SetIndex struct {
+	Object Expr
+	Index  Expr
+	Value  Expr
+	Line   int
+}
+
+func (s *SetIndex) String() string {
+	return fmt.Sprintf("(%s[%s] = %s)", s.Object.String(), s.Index.String(), s.Value.String())
+}
+
+// ImportStmt loads a module: `import "path";` defines its top-level names
+// and `import name from "path";` defines name as the module itself
+type ImportStmt struct {
+	Path string
+	Name string // empty when the names are imported
+	Line int
+}
+
+func (i *ImportStmt) String() string {
+	if i.Name == "" {
+		return fmt.Sprintf("(import %q)", i.Path)
+	}
+	return fmt.Sprintf("(import %s %q)", i.Name, i.Path)
+}
+
+// LineRange returns the first and last source line covered by a node
+func LineRange(node Expr) (int, int) {
+	first, last := 0, 0
+	extend := func(line int) {
+		if line == 0 {
+			return
+		}
+		if first == 0 || line < first {
+			first = line
+		}
+		if line > last {
+			last = line
+		}
+	}
+	extendNode := func(child Expr) {
+		if child != nil {
+			childFirst, childLast := LineRange(child)
+			extend(childFirst)
+			extend(childLast)
+		}
+	}
+
+	switch n := node.(type) {
+	case *Literal:
+		extend(n.Line)
+	case *Identifier:
+		extend(n.Line)
+	case *Grouping:
+		extend(n.Line)
+		extendNode(n.Expression)
+	case *Unary:
+		extend(n.Line)
+		extendNode(n.Right)
+	case *Binary:
+		extendNode(n.Left)
+		extend(n.Line)
+		extendNode(n.Right)
+	case *AssignStmt:
+		extend(n.Line)
+		extendNode(n.Value)
+	case *ExpressionStatement:
+		extend(n.Line)
+		extendNode(n.Expression)
+	case *PrintStatement:
+		extend(n.Line)
+		extendNode(n.Expression)
+	case *VarStmt:
+		extend(n.Line)
+		extendNode(n.Initializer)
+	case *BlockStmt:
+		extend(n.Line)
+		extend(n.EndLine)
+	case *ImportStmt:
+		extend(n.Line)
+	case *ListLiteral:
+		for _, element := range n.Elements {
+			extendNode(element)
+		}
+		extend(n.Line)
+	case *MapLiteral:
+		for i := range n.Keys {
+			extendNode(n.Keys[i])
+			extendNode(n.Values[i])
+		}
+		extend(n.Line)
+	case *Index:
+		extendNode(n.Object)
+		extendNode(n.Index)
+		extend(n.Line)
+	case *SetIndex:
+		extendNode(n.Object)
+		extendNode(n.Index)
+		extend(n.Line)
+		extendNode(n.Value)
+	case *Call:
+		extendNode(n.Callee)
+		for _, argument := range n.Arguments {
+			extendNode(argument)
+		}
+		extend(n.Line)
+	case *Get:
+		extendNode(n.Object)
+		extend(n.Line)
+	case *Set:
+		extendNode(n.Object)
+		extend(n.Line)
+		extendNode(n.Value)
+	}
+	return first, last
+}
+
+// NodeKind returns the name of a node type, such as "Binary"
+func NodeKind(node Expr) string {
+	name := fmt.Sprintf("%T", node)
+	return name[strings.LastIndex(name, ".")+1:]
+}
+
+// StatementLines returns the lines a statement starts on: where breakpoints
+// can be hit and what line coverage counts
+func StatementLines(statements []Stmt) map[int]bool {
+	lines := map[int]bool{}
+	for _, stmt := range statements {
+		if block, ok := stmt.(*BlockStmt); ok {
+			lines[block.Line] = true
+			for line := range StatementLines(block.Statements) {
+				lines[line] = true
+			}
+			continue
+		}
+		line, _ := LineRange(stmt)
+		lines[line] = true
+	}
+	return lines
+}
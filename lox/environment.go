@@ -0,0 +1,118 @@
+package lox
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+type Environment struct {
+	Values map[string]interface{}
+	Parent *Environment
+	Hooks  *Hooks // Shared with every nested environment
+
+	// Context stops evaluation when it is cancelled. It is inherited by
+	// nested environments; nil means evaluation cannot be cancelled.
+	Context context.Context
+
+	// Budget enforces resource limits. It is shared with every nested
+	// environment; nil means no limits.
+	Budget *Budget
+
+	// Stdout receives the output of print statements and Stderr other
+	// diagnostics. Both are inherited by nested environments; nil means
+	// os.Stdout and os.Stderr.
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Modules loads imported modules, shared with every nested environment;
+	// nil where imports are not available
+	Modules *Modules
+
+	// defined records the names given to Define when it is not nil, so a
+	// module knows its own names from the natives it started with
+	defined map[string]bool
+}
+
+// Hooks are callbacks tools like the debugger use to observe evaluation
+type Hooks struct {
+	BeforeStmt []func(stmt Stmt, env *Environment)
+	AfterStmt  []func(stmt Stmt, env *Environment, value interface{})
+	AfterExpr  []func(expr Expr, env *Environment, value interface{})
+}
+
+// Creates a new environment
+func NewEnvironment() *Environment {
+	return &Environment{Values: make(map[string]interface{}), Hooks: &Hooks{}}
+}
+
+// NewEnvironmentWithParent creates a new environment with a reference to a parent environment
+func NewEnvironmentWithParent(parent *Environment) *Environment {
+    return &Environment{
+        Values: make(map[string]interface{}),
+        Parent: parent,
+        Hooks:  parent.Hooks,
+        Context: parent.Context,
+        Budget:  parent.Budget,
+        Stdout:  parent.Stdout,
+        Stderr:  parent.Stderr,
+        Modules: parent.Modules,
+    }
+}
+
+// Define a new variable in environment
+func (e *Environment) Define(name string, value interface{}) {
+	e.Values[name] = value
+	if e.defined != nil {
+		e.defined[name] = true
+	}
+}
+
+// Get the value of a variable, checking parent scopes if necessary
+func (e *Environment) Get(name string) (interface{}, error) {
+    if value, exists := e.Values[name]; exists {
+        return value, nil
+    }
+
+    if e.Parent != nil {
+        return e.Parent.Get(name)
+    }
+
+    return nil, fmt.Errorf("undefined variable '%s'", name)
+}
+
+// Depth returns the number of enclosing environments, 0 for the globals
+func (e *Environment) Depth() int {
+	depth := 0
+	for env := e.Parent; env != nil; env = env.Parent {
+		depth++
+	}
+	return depth
+}
+
+// File returns the absolute path of the file whose code is running, empty
+// when it is not known
+func (e *Environment) File() string {
+	if e.Modules == nil {
+		return ""
+	}
+	return e.Modules.Current()
+}
+
+// stdout returns the writer for print statements. The env is nil when the
+// optimizer folds constants.
+func (e *Environment) stdout() io.Writer {
+	if e == nil || e.Stdout == nil {
+		return os.Stdout
+	}
+	return e.Stdout
+}
+
+// stderr returns the writer for diagnostics
+func (e *Environment) stderr() io.Writer {
+	if e == nil || e.Stderr == nil {
+		return os.Stderr
+	}
+	return e.Stderr
+}
@@ -0,0 +1,609 @@
+// evaluator.go
+package lox
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+)
+
+// Eval method for BlockStmt
+func (b *BlockStmt) Eval(env *Environment) interface{} {
+    // Count the block against the nesting limit, like the parser does
+    if env.Budget != nil {
+        env.Budget.enterNode(b)
+        defer env.Budget.exitNode()
+    }
+
+    // Create a new environment for the block
+    localEnv := NewEnvironmentWithParent(env)
+
+    // Evaluate each statement in the block with the new environment
+    for _, stmt := range b.Statements {
+        ExecuteStatement(stmt, localEnv)
+    }
+
+    return nil
+}
+
+// Eval method for AssignStmt
+func (a *AssignStmt) Eval(env *Environment) interface{} {
+	value := evaluate(a.Value, env) // Evaluate the right-hand side
+	env.Define(a.Name, value)  // Assign the value to the variable
+	return value
+}
+
+// Eval method for VarStmt
+func (v *VarStmt) Eval(env *Environment) interface{} {
+	var value interface{}
+
+	// Check if the variable is used before declaration
+	if !v.VarUsed {
+		_, err := env.Get(v.Name)
+		if err != nil {
+			// Add variable name and line number to the error message
+			panic(newRuntimeError(v.Line, fmt.Sprintf("Cannot use variable '%s' before declaration.", v.Name),
+				fmt.Sprintf("Cannot use variable '%s' before declaration.\n[line %d]\n", v.Name, v.Line), 70))
+		}
+	}
+
+	// Evaluate the initializer if present
+	if v.Initializer != nil {
+		value = evaluate(v.Initializer, env)
+	}
+	
+	// Define the variable in the environment
+	env.Define(v.Name, value)
+	return loxNil
+}
+
+
+// Eval method for ImportStmt loads the module and defines its names, or the
+// module itself under the given name
+func (i *ImportStmt) Eval(env *Environment) interface{} {
+	if env.Modules == nil {
+		raiseError(i.Line, "Cannot import modules here.")
+	}
+	module := env.Modules.Import(i.Path, i.Line, env)
+	if i.Name != "" {
+		env.Define(i.Name, module)
+		return loxNil
+	}
+	for _, name := range module.Names() {
+		env.Define(name, module.Globals.Values[name])
+	}
+	return loxNil
+}
+
+// Eval method for variable
+func (i *Identifier) Eval(env *Environment) interface{} {
+	value, err := env.Get(i.Name)
+	if err != nil {
+		panic(newRuntimeError(i.Line, fmt.Sprintf("Undefined variable '%s'.", i.Name),
+			fmt.Sprintf("Undefined variable '%s'.\n[line %d]\n", i.Name, i.Line), 70)) // Exit with code 70
+	}
+	if value == nil {
+		value = loxNil
+	}
+	return value
+}
+
+// Eval method for PrintStatement
+func (p *PrintStatement) Eval(env *Environment) interface{} {
+	value := evaluate(p.Expression, env) // Evaluate the expression
+	fmt.Fprintln(env.stdout(), Stringify(value)) // Print the evaluated value
+	return nil
+}
+
+// Eval method for ExpressionStatement
+func (e *ExpressionStatement) Eval(env *Environment) interface{} {
+	return evaluate(e.Expression, env) // Evaluate the expression
+}
+
+// Eval method for Literal evaluates and returns the value of the literal
+func (l *Literal) Eval(env *Environment) interface{} {
+	if l.Value == nil {
+		return loxNil
+	}
+	// If it's a number string, convert it
+	if l.Type == "number" {
+		if num, ok := l.Value.(string); ok && isNumber(num) {
+			value, err := ConvertStringToFloat(num, 0) // 0 for line number as it's a literal
+			if err != nil {
+				fmt.Fprintln(env.stderr(), err)
+				return nil
+			}
+			return value
+		}
+	}
+
+	return l.Value // Return the literal value (true, false, or nil)
+}
+
+// Eval method for Grouping evaluates the inner expression
+func (g *Grouping) Eval(env *Environment) interface{} {
+	return evaluate(g.Expression, env) // Evaluate the expression inside parentheses
+}
+
+// Eval method for Unary handles unary operators like ! and -
+func (u *Unary) Eval(env *Environment) interface{} {
+	rightVal := evaluate(u.Right, env) // Evaluate the right-hand expression
+
+	switch u.Operator.Type {
+	case "BANG": // Logical NOT
+		return !isTruthy(rightVal)
+
+	case "MINUS": // Negation
+		switch num := rightVal.(type) {
+		case float64:
+			return -num // Negate the float64 number
+		case int:
+			return -float64(num) // Negate the integer by converting to float64
+		case string:
+			// Use the ConvertStringToFloat function to handle string conversion and error reporting
+			value, err := ConvertStringToFloat(num, u.Line)
+			if err != nil {
+				raiseRuntimeError(u.Line)
+			}
+			return -value // Negate the converted float64 number
+		default:
+			raiseRuntimeError(u.Line)
+		}
+	}
+
+	return nil
+}
+
+// Eval method for Binary expressions (for future operators)
+func (b *Binary) Eval(env *Environment) interface{} {
+	leftVal := evaluate(b.Left, env)
+	rightVal := evaluate(b.Right, env)
+	
+	switch b.Operator.Lexeme {
+	case PLUS: // Handle addition
+		leftNum, leftIsNum := toNumber(leftVal)
+		rightNum, rightIsNum := toNumber(rightVal)
+
+		// Handle addition of numbers
+		if leftIsNum && rightIsNum {
+			return leftNum + rightNum
+		}
+
+		// Handle string concatenation
+		if leftStr, ok := leftVal.(string); ok {
+			if rightStr, ok := rightVal.(string); ok {
+				if env != nil && env.Budget != nil {
+					env.Budget.allocateString(b.Line, len(leftStr)+len(rightStr))
+				}
+				return leftStr + rightStr // Concatenate two strings
+			}
+		}
+
+		// Raise an error for incompatible types
+		raiseRuntimeError(b.Line)
+	case MINUS: // Handle subtraction
+		return handleBinaryNumberOperation(leftVal, rightVal, "-", b.Line)
+	case STAR: // Handle multiplication
+		return handleBinaryNumberOperation(leftVal, rightVal, "*", b.Line)
+	case SLASH: // Handle division
+		leftNum, leftIsNum := toNumber(leftVal)
+		rightNum, rightIsNum := toNumber(rightVal)
+
+		if leftIsNum && rightIsNum {
+			// Check for division by zero
+			if rightNum == 0 {
+				panic(newRuntimeError(b.Line, "Cannot divide by zero",
+					fmt.Sprintf("[line %d] Error: Cannot divide by zero\n", b.Line), 1))
+			}
+			return leftNum / rightNum
+		}
+
+		// Raise an error for incompatible types
+		raiseRuntimeError(b.Line)
+	case GT:
+		leftNum, leftIsNum := toNumber(leftVal)
+		rightNum, rightIsNum := toNumber(rightVal)
+
+		if(leftIsNum && rightIsNum) {
+			return leftNum > rightNum
+		}
+
+		raiseRuntimeError(b.Line)
+	case LT:
+		leftNum, leftIsNum := toNumber(leftVal)
+		rightNum, rightIsNum := toNumber(rightVal)
+
+		if(leftIsNum && rightIsNum) {
+			return leftNum < rightNum
+		}
+
+		raiseRuntimeError(b.Line)
+	case GREATER_EQUAL:
+		leftNum, leftIsNum := toNumber(leftVal)
+		rightNum, rightIsNum := toNumber(rightVal)
+
+		if(leftIsNum && rightIsNum) {
+			return leftNum >= rightNum
+		}
+
+		raiseRuntimeError(b.Line)
+	case LESS_EQUAL:
+		leftNum, leftIsNum := toNumber(leftVal)
+		rightNum, rightIsNum := toNumber(rightVal)
+
+		if(leftIsNum && rightIsNum) {
+			return leftNum <= rightNum
+		}
+
+		raiseRuntimeError(b.Line)
+	case BANG_EQUAL:
+		leftNum, leftIsNum := toNumber(leftVal)
+		rightNum, rightIsNum := toNumber(rightVal)
+
+		if(leftIsNum && rightIsNum) {
+			return leftNum != rightNum
+		} else if !leftIsNum && !rightIsNum {
+			return leftVal != rightVal
+		}
+
+		return false
+	case EQUAL_EQUAL:
+		leftNum, leftIsNum := toNumber(leftVal)
+		rightNum, rightIsNum := toNumber(rightVal)
+
+		if(leftIsNum && rightIsNum) {
+			return leftNum == rightNum
+		} else if !leftIsNum && !rightIsNum {
+			return leftVal == rightVal
+		}
+
+		return false
+	}
+
+	return nil
+}
+
+// Eval method for Call evaluates the callee and the arguments, left to
+// right, and calls the callee
+func (c *Call) Eval(env *Environment) interface{} {
+	callee := evaluate(c.Callee, env)
+	arguments := []Value{}
+	for _, argument := range c.Arguments {
+		arguments = append(arguments, evaluate(argument, env))
+	}
+
+	function, ok := callee.(Callable)
+	if !ok {
+		raiseError(c.Line, "Can only call functions and classes.")
+	}
+	if arity := function.Arity(); arity >= 0 && arity != len(arguments) {
+		raiseError(c.Line, fmt.Sprintf("Expected %d arguments but got %d.", arity, len(arguments)))
+	}
+	checkCancelled(env, c.Line)
+	if env == nil || env.Budget == nil {
+		return callFunction(function, arguments, env, c.Line)
+	}
+	env.Budget.enterCall(c.Line)
+	defer env.Budget.exitCall()
+	return callFunction(function, arguments, env, c.Line)
+}
+
+// callFunction calls a Callable, raising its error at line. A
+// ContextCallable is given the context of the environment. When the program
+// was cancelled during the call, which is why a subprocess run by exec gets
+// killed, the cancellation is raised instead of the error.
+func callFunction(function Callable, arguments []Value, env *Environment, line int) Value {
+	var value Value
+	var err error
+	if f, ok := function.(ContextCallable); ok && env != nil && env.Context != nil {
+		value, err = f.CallContext(env.Context, arguments)
+	} else {
+		value, err = function.Call(arguments)
+	}
+	if err != nil {
+		checkCancelled(env, line)
+		raiseCallError(line, err)
+	}
+	return value
+}
+
+// Eval method for Get reads a property of an object, or the length or a
+// method of a string
+func (g *Get) Eval(env *Environment) interface{} {
+	value := evaluate(g.Object, env)
+	if s, ok := value.(string); ok {
+		property, err := stringProperty(s, g.Name, envBudget(env), g.Line)
+		if err != nil {
+			raiseCallError(g.Line, err)
+		}
+		return property
+	}
+	object, ok := value.(Object)
+	if !ok {
+		raiseError(g.Line, "Only instances have properties.")
+	}
+	value, err := object.Get(g.Name)
+	if err != nil {
+		raiseCallError(g.Line, err)
+	}
+	return value
+}
+
+// Eval method for Set assigns a property of an object
+func (s *Set) Eval(env *Environment) interface{} {
+	object, ok := evaluate(s.Object, env).(Object)
+	if !ok {
+		raiseError(s.Line, "Only instances have fields.")
+	}
+	value := evaluate(s.Value, env)
+	if err := object.Set(s.Name, value); err != nil {
+		raiseCallError(s.Line, err)
+	}
+	return value
+}
+
+// Eval method for ListLiteral evaluates the elements, left to right, into a
+// new list
+func (l *ListLiteral) Eval(env *Environment) interface{} {
+	elements := []Value{}
+	for _, element := range l.Elements {
+		elements = append(elements, evaluate(element, env))
+	}
+	list, err := envBudget(env).newList(elements)
+	if err != nil {
+		raiseCallError(l.Line, err)
+	}
+	return list
+}
+
+// Eval method for MapLiteral evaluates the entries, left to right, into a
+// new map. A repeated key keeps its first position and its last value.
+func (m *MapLiteral) Eval(env *Environment) interface{} {
+	result, err := envBudget(env).newMap()
+	if err != nil {
+		raiseCallError(m.Line, err)
+	}
+	for i := range m.Keys {
+		key := evaluate(m.Keys[i], env)
+		if err := result.SetAt(key, evaluate(m.Values[i], env)); err != nil {
+			raiseCallError(m.Line, err)
+		}
+	}
+	return result
+}
+
+// Eval method for Index reads an element of a list or the value of a key
+func (i *Index) Eval(env *Environment) interface{} {
+	collection, ok := evaluate(i.Object, env).(indexable)
+	if !ok {
+		raiseError(i.Line, "Only lists and maps can be indexed.")
+	}
+	value, err := collection.At(evaluate(i.Index, env))
+	if err != nil {
+		raiseCallError(i.Line, err)
+	}
+	return value
+}
+
+// Eval method for SetIndex assigns an element of a list or a key
+func (s *SetIndex) Eval(env *Environment) interface{} {
+	collection, ok := evaluate(s.Object, env).(indexable)
+	if !ok {
+		raiseError(s.Line, "Only lists and maps can be indexed.")
+	}
+	index := evaluate(s.Index, env)
+	value := evaluate(s.Value, env)
+	if err := collection.SetAt(index, value); err != nil {
+		raiseCallError(s.Line, err)
+	}
+	return value
+}
+
+// envBudget returns the budget of an environment, nil without limits or
+// when the optimizer folds constants without an environment
+func envBudget(env *Environment) *Budget {
+	if env == nil {
+		return nil
+	}
+	return env.Budget
+}
+
+// Helper function to handle number operations (+, -, *, /) for binary expressions
+func handleBinaryNumberOperation(leftVal, rightVal interface{}, operator string, line int) interface{} {
+	leftNum, leftIsNum := toNumber(leftVal)
+	rightNum, rightIsNum := toNumber(rightVal)
+
+	if leftIsNum && rightIsNum {
+		switch operator {
+		case "-":
+			return leftNum - rightNum
+		case "*":
+			return leftNum * rightNum
+		}
+	}
+
+	// Raise an error for incompatible types
+	raiseRuntimeError(line)
+	return nil
+}
+
+// Helper function to check truthiness (used in logical NOT)
+func isTruthy(value interface{}) bool {
+	if value == nil || value == loxNil {
+		return false
+	}
+	if boolean, ok := value.(bool); ok {
+		return boolean
+	}
+	return true
+}
+
+// Helper function to check if a string is a number
+func isNumber(value string) bool {
+	_, err := strconv.ParseFloat(value, 64)
+	return err == nil
+}
+
+// ConvertStringToFloat tries to convert a string to a float64.
+// If it fails, it returns an error with the associated line number.
+func ConvertStringToFloat(input string, line int) (float64, error) {
+	value, err := strconv.ParseFloat(input, 64)
+	if err != nil {
+		return 0, fmt.Errorf("[line %d] Error: Invalid number format '%s'", line, input)
+	}
+	return value, nil
+}
+
+// Helper function to convert interface{} to float64 for number operations
+// Returns a float64 and a boolean indicating if the conversion was successful.
+func toNumber(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case int:
+		return float64(v), true
+	case float64:
+		return v, true
+	default:
+		return 0, false
+	}
+}
+
+
+// Helper function to raise a type error for binary operations
+func raiseRuntimeError(line int) {
+	panic(newRuntimeError(line, "Operands must be a number.", fmt.Sprintf("Operands must be a number.\n[line %d]", line), 70))
+}
+
+// raiseError stops the program with a runtime error reported like
+// "Undefined variable", the message followed by the line
+func raiseError(line int, message string) {
+	panic(newRuntimeError(line, message, fmt.Sprintf("%s\n[line %d]\n", message, line), 70))
+}
+
+// raiseCallError raises an error returned by a native or an object. Runtime
+// errors are passed on as they are, other errors are reported at line.
+func raiseCallError(line int, err error) {
+	if runtimeErr, ok := err.(*RuntimeError); ok {
+		panic(runtimeErr)
+	}
+	message := err.Error()
+	runtimeErr := newRuntimeError(line, message, fmt.Sprintf("%s\n[line %d]\n", message, line), 70)
+	runtimeErr.cause = err
+	panic(runtimeErr)
+}
+
+// Exit codes of a program stopped through its context, following the shell
+// conventions for timeout(1) and Ctrl-C
+const (
+	TimeoutExitCode   = 124
+	CancelledExitCode = 130
+)
+
+// checkCancelled stops the program with a runtime error once the context of
+// the environment is done. It is checked before every statement, which is
+// the back-edge of every block, and before every call.
+func checkCancelled(env *Environment, line int) {
+	if env == nil || env.Context == nil {
+		return
+	}
+	err := env.Context.Err()
+	if err == nil {
+		return
+	}
+	message, exitCode := "Execution cancelled.", CancelledExitCode
+	if errors.Is(err, context.DeadlineExceeded) {
+		message, exitCode = "Execution timed out.", TimeoutExitCode
+	}
+	runtimeErr := newRuntimeError(line, message, fmt.Sprintf("%s\n[line %d]\n", message, line), exitCode)
+	runtimeErr.cause = err
+	panic(runtimeErr)
+}
+
+// RuntimeError stops the evaluation of a program. It is raised with panic so
+// it unwinds through the nested Eval calls, and is recovered by
+// Interpreter.Execute or by tools like the debugger.
+type RuntimeError struct {
+	Line     int
+	Message  string
+	ExitCode int
+	report   string // text written to stderr before exiting
+	cause    error  // the error of a native, or the context error of a cancelled program
+}
+
+func newRuntimeError(line int, message, report string, exitCode int) *RuntimeError {
+	return &RuntimeError{Line: line, Message: message, ExitCode: exitCode, report: report}
+}
+
+// Report returns the text the command line interpreter prints for the error
+func (e *RuntimeError) Report() string {
+	return e.report
+}
+
+func (e *RuntimeError) Error() string {
+	return fmt.Sprintf("%s\n[line %d]", e.Message, e.Line)
+}
+
+// Unwrap returns the error that caused a runtime error, so hosts can test
+// errors.Is(err, context.DeadlineExceeded) or errors.Is(err, ErrPermissionDenied)
+func (e *RuntimeError) Unwrap() error {
+	return e.cause
+}
+
+// Evaluate evaluates an expression, returning a runtime error instead of
+// stopping the program
+func Evaluate(expr Expr, env *Environment) (value interface{}, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			runtimeErr, ok := r.(*RuntimeError)
+			if !ok {
+				panic(r)
+			}
+			err = runtimeErr
+		}
+	}()
+	return evaluate(expr, env), nil
+}
+
+// ExecuteStatement runs a statement, giving the environment hooks a chance
+// to observe it before and after. Both the top-level loop and blocks go
+// through here, so it is also where a cancelled program stops. A runtime
+// error panics with a *RuntimeError, which callers recover at the top level.
+func ExecuteStatement(stmt Stmt, env *Environment) interface{} {
+	if env.Context != nil && env.Context.Err() != nil {
+		line, _ := LineRange(stmt)
+		checkCancelled(env, line)
+	}
+	if env.Budget != nil {
+		env.Budget.step(stmt)
+	}
+	if env.Hooks != nil {
+		for _, hook := range env.Hooks.BeforeStmt {
+			hook(stmt, env)
+		}
+	}
+	value := stmt.Eval(env)
+	if env.Hooks != nil {
+		for _, hook := range env.Hooks.AfterStmt {
+			hook(stmt, env, value)
+		}
+	}
+	return value
+}
+
+// evaluate evaluates a sub-expression, counting it and its nesting against
+// the budget, and passes the result to the AfterExpr hooks. The env is nil
+// when the optimizer folds constants.
+func evaluate(expr Expr, env *Environment) interface{} {
+	if env != nil && env.Budget != nil {
+		env.Budget.step(expr)
+		env.Budget.enterNode(expr)
+		defer env.Budget.exitNode()
+	}
+	value := expr.Eval(env)
+	if env != nil && env.Hooks != nil {
+		for _, hook := range env.Hooks.AfterExpr {
+			hook(expr, env, value)
+		}
+	}
+	return value
+}
\ No newline at end of file
@@ -0,0 +1,186 @@
+// host.go
+package lox
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+)
+
+// Capabilities are what a script may do on the host through the natives
+// readFile, writeFile, getenv and exec. The zero value allows nothing, so an
+// interpreter only touches the host when it is given permission.
+type Capabilities struct {
+	Read  []string // files under these paths may be read
+	Write []string // files under these paths may be written
+	Env   []string // names of the environment variables getenv may read
+	Exec  bool     // whether exec may run subprocesses
+}
+
+// ErrPermissionDenied is the error of a native refused by the capabilities
+var ErrPermissionDenied = errors.New("Permission denied")
+
+func permissionDenied(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s.", ErrPermissionDenied, fmt.Sprintf(format, args...))
+}
+
+// CanRead reports whether the file at path may be read
+func (c Capabilities) CanRead(path string) bool {
+	return underAny(path, c.Read)
+}
+
+// CanWrite reports whether the file at path may be written
+func (c Capabilities) CanWrite(path string) bool {
+	return underAny(path, c.Write)
+}
+
+// CanGetenv reports whether the environment variable may be read
+func (c Capabilities) CanGetenv(name string) bool {
+	for _, allowed := range c.Env {
+		if allowed == name {
+			return true
+		}
+	}
+	return false
+}
+
+// underAny reports whether path is one of the prefixes or inside one. Both
+// are made absolute and symbolic links resolved, so neither ".." nor a link
+// leads out of an allowed directory.
+func underAny(path string, prefixes []string) bool {
+	resolved, err := resolvePath(path)
+	if err != nil {
+		return false
+	}
+	for _, prefix := range prefixes {
+		allowed, err := resolvePath(prefix)
+		if err != nil {
+			continue
+		}
+		relative, err := filepath.Rel(allowed, resolved)
+		if err == nil && relative != ".." && !strings.HasPrefix(relative, ".."+string(filepath.Separator)) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvePath makes a path absolute and resolves symbolic links in it. A
+// file that does not exist yet, as written by writeFile, is resolved
+// through the directory it would be created in.
+func resolvePath(path string) (string, error) {
+	absolute, err := filepath.Abs(path)
+	if err != nil {
+		return "", err
+	}
+	resolved, err := filepath.EvalSymlinks(absolute)
+	if errors.Is(err, fs.ErrNotExist) {
+		dir, err := resolvePath(filepath.Dir(absolute))
+		if err != nil {
+			return "", err
+		}
+		return filepath.Join(dir, filepath.Base(absolute)), nil
+	}
+	return resolved, err
+}
+
+// defineHostNatives defines the natives that reach outside the interpreter in
+// a global environment.
+// Each one checks the capabilities of the interpreter before acting.
+func (i *Interpreter) defineHostNatives(globals *Environment) {
+	capabilities := i.options.Capabilities
+
+	globals.Define("readFile", &NativeFunction{Name: "readFile", Params: 1, Function: func(arguments []Value) (Value, error) {
+		path, err := stringArgument("readFile", arguments, 0)
+		if err != nil {
+			return nil, err
+		}
+		if !capabilities.CanRead(path) {
+			return nil, permissionDenied("cannot read '%s'", path)
+		}
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("Cannot read '%s': %v.", path, errors.Unwrap(err))
+		}
+		return string(content), nil
+	}})
+
+	globals.Define("writeFile", &NativeFunction{Name: "writeFile", Params: 2, Function: func(arguments []Value) (Value, error) {
+		path, err := stringArgument("writeFile", arguments, 0)
+		if err != nil {
+			return nil, err
+		}
+		content, err := stringArgument("writeFile", arguments, 1)
+		if err != nil {
+			return nil, err
+		}
+		if !capabilities.CanWrite(path) {
+			return nil, permissionDenied("cannot write '%s'", path)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			return nil, fmt.Errorf("Cannot write '%s': %v.", path, errors.Unwrap(err))
+		}
+		return loxNil, nil
+	}})
+
+	globals.Define("getenv", &NativeFunction{Name: "getenv", Params: 1, Function: func(arguments []Value) (Value, error) {
+		name, err := stringArgument("getenv", arguments, 0)
+		if err != nil {
+			return nil, err
+		}
+		if !capabilities.CanGetenv(name) {
+			return nil, permissionDenied("cannot read environment variable '%s'", name)
+		}
+		value, ok := os.LookupEnv(name)
+		if !ok {
+			return loxNil, nil
+		}
+		return value, nil
+	}})
+
+	// exec runs a command with arguments, without a shell, and returns its
+	// standard output. It is stopped with the program when that is cancelled.
+	globals.Define("exec", &NativeFunction{Name: "exec", Params: -1, Function: func(arguments []Value) (Value, error) {
+		if len(arguments) == 0 {
+			return nil, errors.New("Expected at least 1 arguments but got 0.")
+		}
+		args := []string{}
+		for index := range arguments {
+			arg, err := stringArgument("exec", arguments, index)
+			if err != nil {
+				return nil, err
+			}
+			args = append(args, arg)
+		}
+		if !capabilities.Exec {
+			return nil, permissionDenied("cannot run '%s'", args[0])
+		}
+
+		ctx := globals.Context
+		if ctx == nil {
+			ctx = context.Background()
+		}
+		var stdout bytes.Buffer
+		cmd := exec.CommandContext(ctx, args[0], args[1:]...)
+		cmd.Stdout = &stdout
+		cmd.Stderr = globals.stderr()
+		if err := cmd.Run(); err != nil {
+			return nil, fmt.Errorf("Command '%s' failed: %v.", args[0], err)
+		}
+		return stdout.String(), nil
+	}})
+}
+
+// stringArgument returns an argument of a native that must be a string
+func stringArgument(function string, arguments []Value, index int) (string, error) {
+	if s, ok := arguments[index].(string); ok {
+		return s, nil
+	}
+	return "", fmt.Errorf("Argument %d of '%s' must be a string, got %s.", index+1, function, typeName(arguments[index]))
+}
@@ -0,0 +1,479 @@
+// interop.go
+package lox
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"reflect"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var (
+	errorType   = reflect.TypeOf((*error)(nil)).Elem()
+	contextType = reflect.TypeOf((*context.Context)(nil)).Elem()
+)
+
+// Bind converts a Go value with ToValue and defines it in the environment.
+// Functions become callable from Lox and structs expose their exported
+// fields and methods. The lists, maps and struct copies they return to the
+// script count against the budget of the environment.
+//
+//	env.Bind("add", func(a, b int) int { return a + b })
+//	env.Bind("point", &Point{X: 1, Y: 2}) // point.X, point.Norm()
+func (e *Environment) Bind(name string, value interface{}) error {
+	converted, err := toValue(reflect.ValueOf(value), name, e.Budget)
+	if err != nil {
+		return fmt.Errorf("cannot bind '%s': %v", name, err)
+	}
+	e.Define(name, converted)
+	return nil
+}
+
+// ToValue converts a Go value to a Lox value. Booleans and strings are kept,
+// every integer and float type becomes a float64 and nil becomes Lox nil.
+// Slices and arrays are copied into a new *List and maps into a new *Map,
+// with their keys sorted. Functions become a *GoFunction; structs and
+// pointers to structs become a *GoValue. Values that already implement
+// Callable or Object are used as they are. Channels and complex numbers have
+// no Lox counterpart and are an error.
+func ToValue(value interface{}) (Value, error) {
+	return toValue(reflect.ValueOf(value), "", nil)
+}
+
+// toValue converts a Go value, counting the objects it creates against the
+// budget, which may be nil
+func toValue(v reflect.Value, name string, budget *Budget) (Value, error) {
+	if !v.IsValid() {
+		return loxNil, nil
+	}
+	if v.CanInterface() {
+		switch v.Interface().(type) {
+		case Callable, Object:
+			return v.Interface(), nil
+		}
+	}
+
+	switch v.Kind() {
+	case reflect.Bool:
+		return v.Bool(), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return float64(v.Uint()), nil
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), nil
+	case reflect.String:
+		return v.String(), nil
+	case reflect.Interface:
+		if v.IsNil() {
+			return loxNil, nil
+		}
+		return toValue(v.Elem(), name, budget)
+	case reflect.Ptr:
+		if v.IsNil() {
+			return loxNil, nil
+		}
+		if v.Elem().Kind() == reflect.Struct {
+			return &GoValue{value: v, budget: budget}, nil
+		}
+		return toValue(v.Elem(), name, budget)
+	case reflect.Struct:
+		// Copied behind a pointer so methods with pointer receivers work and
+		// fields can be assigned. Bind a pointer to share the struct.
+		if err := budget.allocateObject(); err != nil {
+			return nil, err
+		}
+		pointer := reflect.New(v.Type())
+		pointer.Elem().Set(v)
+		return &GoValue{value: pointer, budget: budget}, nil
+	case reflect.Slice, reflect.Array:
+		if v.Kind() == reflect.Slice && v.IsNil() {
+			return loxNil, nil
+		}
+		elements := make([]Value, v.Len())
+		for i := range elements {
+			element, err := toValue(v.Index(i), "", budget)
+			if err != nil {
+				return nil, err
+			}
+			elements[i] = element
+		}
+		return budget.newList(elements)
+	case reflect.Map:
+		if v.IsNil() {
+			return loxNil, nil
+		}
+		return mapToValue(v, budget)
+	case reflect.Func:
+		if v.IsNil() {
+			return loxNil, nil
+		}
+		return newGoFunction(name, v, budget)
+	}
+	return nil, fmt.Errorf("Go type %s has no Lox equivalent", v.Type())
+}
+
+// mapToValue copies a Go map into a new *Map. Go does not order its maps, so
+// the keys are sorted to give scripts the same order on every run.
+func mapToValue(v reflect.Value, budget *Budget) (Value, error) {
+	keys := []Value{}
+	values := map[Value]Value{}
+	iter := v.MapRange()
+	for iter.Next() {
+		key, err := toValue(iter.Key(), "", budget)
+		if err != nil {
+			return nil, err
+		}
+		value, err := toValue(iter.Value(), "", budget)
+		if err != nil {
+			return nil, err
+		}
+		if key, err = mapKey(key); err != nil {
+			return nil, fmt.Errorf("Go map key of type %s has no Lox equivalent", v.Type().Key())
+		}
+		keys = append(keys, key)
+		values[key] = value
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keyLess(keys[i], keys[j])
+	})
+
+	result, err := budget.newMap()
+	if err != nil {
+		return nil, err
+	}
+	for _, key := range keys {
+		result.SetAt(key, values[key])
+	}
+	return result, nil
+}
+
+// keyLess orders map keys: nil, then booleans, numbers and strings, each in
+// their natural order
+func keyLess(a, b Value) bool {
+	rank := func(key Value) int {
+		switch key.(type) {
+		case nilValue:
+			return 0
+		case bool:
+			return 1
+		case float64:
+			return 2
+		}
+		return 3
+	}
+	if rank(a) != rank(b) {
+		return rank(a) < rank(b)
+	}
+	switch a := a.(type) {
+	case bool:
+		return !a && b.(bool)
+	case float64:
+		return a < b.(float64)
+	case string:
+		return a < b.(string)
+	}
+	return false
+}
+
+// FromValue converts a Lox value to the Go value closest to it: Lox nil
+// becomes nil, a *GoValue or *GoFunction the Go value it wraps, a list a
+// []interface{} and a map a map[interface{}]interface{}
+func FromValue(value Value) interface{} {
+	switch v := value.(type) {
+	case nilValue:
+		return nil
+	case *GoValue:
+		return v.value.Interface()
+	case *GoFunction:
+		return v.function.Interface()
+	case *List:
+		elements := make([]interface{}, len(v.Elements))
+		for i, element := range v.Elements {
+			elements[i] = FromValue(element)
+		}
+		return elements
+	case *Map:
+		entries := make(map[interface{}]interface{}, len(v.keys))
+		for _, key := range v.keys {
+			entries[FromValue(key)] = FromValue(v.entries[key])
+		}
+		return entries
+	}
+	return value
+}
+
+// fromValue converts a Lox value to a Go value of the target type, reporting
+// whether that is possible. Numbers must be whole and in range for integer
+// types.
+func fromValue(value Value, target reflect.Type) (reflect.Value, bool) {
+	if value == nil || value == loxNil {
+		switch target.Kind() {
+		case reflect.Ptr, reflect.Interface, reflect.Slice, reflect.Map, reflect.Func:
+			return reflect.Zero(target), true
+		}
+		return reflect.Value{}, false
+	}
+
+	switch target.Kind() {
+	case reflect.Bool:
+		if b, ok := value.(bool); ok {
+			return reflect.ValueOf(b).Convert(target), true
+		}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		if n, ok := toNumber(value); ok && n == math.Trunc(n) && !reflect.Zero(target).OverflowInt(int64(n)) {
+			return reflect.ValueOf(int64(n)).Convert(target), true
+		}
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		if n, ok := toNumber(value); ok && n >= 0 && n == math.Trunc(n) && !reflect.Zero(target).OverflowUint(uint64(n)) {
+			return reflect.ValueOf(uint64(n)).Convert(target), true
+		}
+	case reflect.Float32, reflect.Float64:
+		if n, ok := toNumber(value); ok {
+			return reflect.ValueOf(n).Convert(target), true
+		}
+	case reflect.String:
+		if s, ok := value.(string); ok {
+			return reflect.ValueOf(s).Convert(target), true
+		}
+	case reflect.Slice:
+		// A list is copied into a slice of the target type
+		if list, ok := value.(*List); ok {
+			slice := reflect.MakeSlice(target, len(list.Elements), len(list.Elements))
+			for i, element := range list.Elements {
+				converted, ok := fromValue(element, target.Elem())
+				if !ok {
+					return reflect.Value{}, false
+				}
+				slice.Index(i).Set(converted)
+			}
+			return slice, true
+		}
+		fallthrough
+	case reflect.Map:
+		// A map is copied into a map of the target type
+		if m, ok := value.(*Map); ok && target.Kind() == reflect.Map {
+			result := reflect.MakeMapWithSize(target, len(m.keys))
+			for _, key := range m.keys {
+				k, ok := fromValue(key, target.Key())
+				if !ok {
+					return reflect.Value{}, false
+				}
+				v, ok := fromValue(m.entries[key], target.Elem())
+				if !ok {
+					return reflect.Value{}, false
+				}
+				result.SetMapIndex(k, v)
+			}
+			return result, true
+		}
+		fallthrough
+	default:
+		goValue := reflect.ValueOf(FromValue(value))
+		if goValue.Type().AssignableTo(target) {
+			return goValue, true
+		}
+		// A struct is held behind a pointer but may be passed by value
+		if goValue.Kind() == reflect.Ptr && goValue.Elem().Type().AssignableTo(target) {
+			return goValue.Elem(), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// describeType names a Go type the way Lox scripts see it
+func describeType(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Uintptr:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.Bool:
+		return "a boolean"
+	case reflect.String:
+		return "a string"
+	}
+	return t.String()
+}
+
+// GoFunction is a Go function called from Lox through reflection. It may
+// return nothing, a value, an error, or a value and an error; a non-nil
+// error becomes a runtime error. A function whose first parameter is a
+// context.Context is given the context of the program, which is cancelled
+// when the program is, and Lox passes only the remaining arguments:
+//
+//	env.Bind("fetch", func(ctx context.Context, url string) (string, error) { ... })
+type GoFunction struct {
+	name         string
+	function     reflect.Value
+	budget       *Budget // counts the objects in results
+	takesContext bool
+}
+
+func newGoFunction(name string, function reflect.Value, budget *Budget) (*GoFunction, error) {
+	t := function.Type()
+	results := t.NumOut()
+	if results > 0 && t.Out(results-1) == errorType {
+		results--
+	}
+	if results > 1 {
+		return nil, fmt.Errorf("function %s returns more than one value besides an error", t)
+	}
+	takesContext := t.NumIn() > 0 && t.In(0) == contextType
+	return &GoFunction{name: name, function: function, budget: budget, takesContext: takesContext}, nil
+}
+
+// Arity is the number of parameters passed from Lox, or -1 for a variadic
+// function
+func (f *GoFunction) Arity() int {
+	if f.function.Type().IsVariadic() {
+		return -1
+	}
+	return f.params()
+}
+
+// params is the number of parameters passed from Lox, not counting the
+// context
+func (f *GoFunction) params() int {
+	if f.takesContext {
+		return f.function.Type().NumIn() - 1
+	}
+	return f.function.Type().NumIn()
+}
+
+// Call calls the function with a background context
+func (f *GoFunction) Call(arguments []Value) (Value, error) {
+	return f.CallContext(context.Background(), arguments)
+}
+
+// CallContext converts the arguments, calls the function and converts its
+// result. A panic in the function is turned into an error.
+func (f *GoFunction) CallContext(ctx context.Context, arguments []Value) (result Value, err error) {
+	t := f.function.Type()
+	if t.IsVariadic() && len(arguments) < f.params()-1 {
+		return nil, fmt.Errorf("Expected at least %d arguments but got %d.", f.params()-1, len(arguments))
+	}
+
+	in := []reflect.Value{}
+	if f.takesContext {
+		in = append(in, reflect.ValueOf(&ctx).Elem())
+	}
+	for i, argument := range arguments {
+		position := len(in)
+		param := t.In(min(position, t.NumIn()-1))
+		if t.IsVariadic() && position >= t.NumIn()-1 {
+			param = param.Elem()
+		}
+		converted, ok := fromValue(argument, param)
+		if !ok {
+			return nil, fmt.Errorf("Argument %d of '%s' must be %s, got %s.", i+1, f.name, describeType(param), typeName(argument))
+		}
+		in = append(in, converted)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			result, err = nil, fmt.Errorf("Go function '%s' panicked: %v", f.name, r)
+		}
+	}()
+	out := f.function.Call(in)
+
+	if len(out) > 0 && t.Out(len(out)-1) == errorType {
+		if callErr, _ := out[len(out)-1].Interface().(error); callErr != nil {
+			return nil, callErr
+		}
+		out = out[:len(out)-1]
+	}
+	if len(out) == 0 {
+		return loxNil, nil
+	}
+	return toValue(out[0], "", f.budget)
+}
+
+func (f *GoFunction) String() string {
+	return "<native fn>"
+}
+
+// GoValue is a Go struct used from Lox. Its exported fields and methods are
+// its properties, found by their Go name or with a lower case first letter
+// (point.x, point.norm()).
+type GoValue struct {
+	value  reflect.Value
+	budget *Budget // counts the objects in fields and method results
+}
+
+// Interface returns the Go value
+func (g *GoValue) Interface() interface{} {
+	return g.value.Interface()
+}
+
+func (g *GoValue) Get(name string) (Value, error) {
+	for _, candidate := range goNames(name) {
+		if method := g.value.MethodByName(candidate); method.IsValid() {
+			return newGoFunction(name, method, g.budget)
+		}
+		if field, ok := g.field(candidate); ok {
+			return toValue(field, name, g.budget)
+		}
+	}
+	return nil, fmt.Errorf("Undefined property '%s'.", name)
+}
+
+func (g *GoValue) Set(name string, value Value) error {
+	for _, candidate := range goNames(name) {
+		field, ok := g.field(candidate)
+		if !ok {
+			continue
+		}
+		if !field.CanSet() {
+			return fmt.Errorf("Field '%s' cannot be assigned.", name)
+		}
+		converted, ok := fromValue(value, field.Type())
+		if !ok {
+			return fmt.Errorf("Field '%s' must be %s, got %s.", name, describeType(field.Type()), typeName(value))
+		}
+		field.Set(converted)
+		return nil
+	}
+	return fmt.Errorf("Undefined property '%s'.", name)
+}
+
+// field finds an exported struct field
+func (g *GoValue) field(name string) (reflect.Value, bool) {
+	v := g.value
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return reflect.Value{}, false
+	}
+	field, ok := v.Type().FieldByName(name)
+	if !ok || !field.IsExported() {
+		return reflect.Value{}, false
+	}
+	return v.FieldByIndex(field.Index), true
+}
+
+func (g *GoValue) String() string {
+	if stringer, ok := g.value.Interface().(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	if g.value.Kind() == reflect.Ptr {
+		return g.value.Elem().Type().Name() + " instance"
+	}
+	return fmt.Sprint(g.value.Interface())
+}
+
+// goNames returns the Go names a Lox property name may refer to: the name
+// itself and the name with an upper case first letter
+func goNames(name string) []string {
+	exported := strings.ToUpper(name[:1]) + name[1:]
+	if exported == name || !unicode.IsLetter(rune(name[0])) {
+		return []string{name}
+	}
+	return []string{name, exported}
+}
@@ -0,0 +1,255 @@
+package lox_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/codecrafters-io/interpreter-starter-go/lox"
+)
+
+type point struct {
+	X, Y  float64
+	Label string
+}
+
+func (p *point) Norm() float64 {
+	return math.Sqrt(p.X*p.X + p.Y*p.Y)
+}
+
+func (p *point) Move(dx, dy float64) {
+	p.X += dx
+	p.Y += dy
+}
+
+var errNotFound = errors.New("not found")
+
+// newBoundInterpreter returns an interpreter with the Go values used by the
+// tests bound, and the buffer its print statements write to
+func newBoundInterpreter(t *testing.T) (*lox.Interpreter, *bytes.Buffer) {
+	t.Helper()
+	var stdout bytes.Buffer
+	interpreter := lox.New(lox.Options{Stdout: &stdout, Stderr: io.Discard})
+	bindings := map[string]interface{}{
+		"add":    func(a, b int) int { return a + b },
+		"half":   func(x float64) float64 { return x / 2 },
+		"greet":  func(name string) string { return "hello " + name },
+		"negate": func(b bool) bool { return !b },
+		"sum": func(xs ...int) int {
+			total := 0
+			for _, x := range xs {
+				total += x
+			}
+			return total
+		},
+		"join":   func(sep string, parts ...string) string { return strings.Join(parts, sep) },
+		"nums":   func() []int { return []int{4, 5, 6} },
+		"total":  func(xs []float64) float64 { return xs[0] + xs[len(xs)-1] },
+		"ages":   map[string]int{"bo": 3, "al": 2},
+		"count":  func(m map[string]int) int { return len(m) },
+		"lookup": func(key string) (int, error) { return 0, fmt.Errorf("%w: %s", errNotFound, key) },
+		"boom":   func() { panic("kaboom") },
+		"origin": &point{Label: "origin"},
+		"maybe":  func(p *point) bool { return p == nil },
+	}
+	for name, value := range bindings {
+		if err := interpreter.Globals.Bind(name, value); err != nil {
+			t.Fatalf("Bind(%q): %v", name, err)
+		}
+	}
+	return interpreter, &stdout
+}
+
+func TestBindCallsGoFunctions(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{`print add(1, 2);`, "3"},
+		{`print half(3);`, "1.5"},
+		{`print greet("lox");`, "hello lox"},
+		{`print negate(false);`, "true"},
+		{`print sum();`, "0"},
+		{`print sum(1, 2, 3);`, "6"},
+		{`print join("-", "a", "b", "c");`, "a-b-c"},
+		{`print nums()[1];`, "5"},
+		{`print nums();`, "[4, 5, 6]"},
+		{`print total([1, 2.5, 4]);`, "5"},
+		{`print ages;`, `{"al": 2, "bo": 3}`},
+		{`print count({"a": 1});`, "1"},
+		{`print maybe(nil);`, "true"},
+	}
+	for _, test := range tests {
+		interpreter, stdout := newBoundInterpreter(t)
+		if _, err := interpreter.Run(context.Background(), test.source); err != nil {
+			t.Errorf("%s: %v", test.source, err)
+			continue
+		}
+		if got := strings.TrimSuffix(stdout.String(), "\n"); got != test.want {
+			t.Errorf("%s printed %q, want %q", test.source, got, test.want)
+		}
+	}
+}
+
+func TestBindReportsArgumentErrors(t *testing.T) {
+	tests := []struct {
+		source string
+		want   string
+	}{
+		{`add("1", 2);`, "Argument 1 of 'add' must be an integer, got string."},
+		{`add(1, 2.5);`, "Argument 2 of 'add' must be an integer, got number."},
+		{`add(1);`, "Expected 2 arguments but got 1."},
+		{`greet(nil);`, "Argument 1 of 'greet' must be a string, got nil."},
+		{`negate(1);`, "Argument 1 of 'negate' must be a boolean, got number."},
+		{`join();`, "Expected at least 1 arguments but got 0."},
+		{`sum(1, "2");`, "Argument 2 of 'sum' must be an integer, got string."},
+		{`total(["a"]);`, "Argument 1 of 'total' must be []float64, got list."},
+		{`boom();`, "Go function 'boom' panicked: kaboom"},
+		{`origin.X = "far";`, "Field 'X' must be a number, got string."},
+		{`origin.z;`, "Undefined property 'z'."},
+	}
+	for _, test := range tests {
+		interpreter, _ := newBoundInterpreter(t)
+		_, err := interpreter.Run(context.Background(), test.source)
+		var runtimeErr *lox.RuntimeError
+		if !errors.As(err, &runtimeErr) {
+			t.Errorf("%s: got %v, want a runtime error", test.source, err)
+			continue
+		}
+		if runtimeErr.Message != test.want {
+			t.Errorf("%s: got %q, want %q", test.source, runtimeErr.Message, test.want)
+		}
+	}
+}
+
+func TestBindReturnsGoErrors(t *testing.T) {
+	interpreter, _ := newBoundInterpreter(t)
+	_, err := interpreter.Run(context.Background(), `lookup("key");`)
+	if !errors.Is(err, errNotFound) {
+		t.Fatalf("got %v, want an error wrapping errNotFound", err)
+	}
+	if code := lox.ExitCode(err); code != 70 {
+		t.Errorf("exit code %d, want 70", code)
+	}
+}
+
+func TestBindStructFieldsAndMethods(t *testing.T) {
+	interpreter, stdout := newBoundInterpreter(t)
+	p := &point{X: 3, Y: 4}
+	if err := interpreter.Globals.Bind("p", p); err != nil {
+		t.Fatal(err)
+	}
+	source := `
+print p.x;
+print p.Y;
+print p.norm();
+p.move(1, 1);
+p.label = "moved";
+print p.Label;`
+	if _, err := interpreter.Run(context.Background(), source); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := stdout.String(), "3\n4\n5\nmoved\n"; got != want {
+		t.Errorf("printed %q, want %q", got, want)
+	}
+	if *p != (point{X: 4, Y: 5, Label: "moved"}) {
+		t.Errorf("the Go struct is %+v after the script changed it", *p)
+	}
+}
+
+func TestBindRejectsTypesWithoutLoxEquivalent(t *testing.T) {
+	env := lox.NewEnvironment()
+	for name, value := range map[string]interface{}{
+		"channel": make(chan int),
+		"complex": complex(1, 2),
+		"pair":    func() (int, int) { return 1, 2 },
+		"keyed":   map[point]int{{}: 1},
+	} {
+		if err := env.Bind(name, value); err == nil {
+			t.Errorf("Bind(%q) succeeded, want an error", name)
+		}
+	}
+}
+
+func TestToValueAndFromValue(t *testing.T) {
+	tests := []struct {
+		goValue interface{}
+		want    interface{}
+	}{
+		{nil, nil},
+		{3, 3.0},
+		{uint8(7), 7.0},
+		{float32(0.5), 0.5},
+		{"nil", "nil"},
+		{true, true},
+		{[]string{"a", "b"}, []interface{}{"a", "b"}},
+		{[2]int{1, 2}, []interface{}{1.0, 2.0}},
+		{map[string]bool{"x": true}, map[interface{}]interface{}{"x": true}},
+		{[]interface{}{nil, []int{1}}, []interface{}{nil, []interface{}{1.0}}},
+	}
+	for _, test := range tests {
+		value, err := lox.ToValue(test.goValue)
+		if err != nil {
+			t.Errorf("ToValue(%#v): %v", test.goValue, err)
+			continue
+		}
+		if got := lox.FromValue(value); !reflect.DeepEqual(got, test.want) {
+			t.Errorf("FromValue(ToValue(%#v)) = %#v, want %#v", test.goValue, got, test.want)
+		}
+	}
+
+	p := &point{X: 1}
+	value, err := lox.ToValue(p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := lox.FromValue(value); got != p {
+		t.Errorf("FromValue of a bound struct pointer = %#v, want the same pointer", got)
+	}
+}
+
+func TestRunReturnsGoNilForLoxNil(t *testing.T) {
+	interpreter := lox.New(lox.Options{Stderr: io.Discard})
+	tests := []struct {
+		source string
+		want   interface{}
+	}{
+		{`nil;`, nil},
+		{`"nil";`, "nil"},
+		{`1 + 2;`, 3.0},
+		{`var unset;`, nil},
+	}
+	for _, test := range tests {
+		got, err := interpreter.Run(context.Background(), test.source)
+		if err != nil {
+			t.Errorf("%s: %v", test.source, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s returned %#v, want %#v", test.source, got, test.want)
+		}
+	}
+}
+
+func TestRunReturnsTheValueOfABareVariable(t *testing.T) {
+	interpreter := lox.New(lox.Options{Stderr: io.Discard})
+	value, err := interpreter.Run(context.Background(), `var m = {"a": 1}; m;`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := map[interface{}]interface{}{"a": 1.0}; !reflect.DeepEqual(lox.FromValue(value), want) {
+		t.Errorf("m; returned %#v, want %#v", lox.FromValue(value), want)
+	}
+
+	// Reading the variable leaves it as it was
+	value, err = interpreter.Run(context.Background(), `m["a"];`)
+	if err != nil || value != 1.0 {
+		t.Errorf(`m["a"] returned %#v, %v after m; want 1`, value, err)
+	}
+}
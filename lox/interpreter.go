@@ -0,0 +1,169 @@
+// interpreter.go
+
+// Package lox is a tree-walking interpreter for the Lox language. Hosts
+// embed it through Interpreter:
+//
+//	interpreter := lox.New(lox.Options{})
+//	value, err := interpreter.Run(ctx, "1 + 2 * 3;") // 7
+//
+// The lexer, parser, syntax tree and Environment are exported as well, for
+// tools that work on programs rather than just run them.
+package lox
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+)
+
+// Value is a Lox value: float64, string, bool, a *List or *Map, a *Module or
+// *Namespace, a Callable such as *NativeFunction or *GoFunction, or a
+// *GoValue wrapping a bound Go value. Lox nil is Go nil in the values Run,
+// Execute and FromValue return.
+type Value = interface{}
+
+// Options configure an Interpreter
+type Options struct {
+	Optimize bool   // fold constant expressions before running, as `run` does
+	Limits   Limits // resources each Execute may use, unlimited when zero
+
+	// Stdout receives the output of print statements and Stderr the errors,
+	// os.Stdout and os.Stderr when nil. Give each interpreter its own
+	// writers to capture output or to run several side by side.
+	Stdout io.Writer
+	Stderr io.Writer
+
+	// Capabilities are what the natives readFile, writeFile, getenv and exec
+	// may do on the host; nothing by default
+	Capabilities Capabilities
+
+	// Filename is the file the program was read from, which imports are
+	// resolved relative to; the working directory is used without one.
+	// SearchPath lists more directories to find imported modules in.
+	Filename   string
+	SearchPath []string
+}
+
+// Interpreter runs Lox programs in a global environment that persists
+// between runs, so a host can define variables in one snippet and use them
+// in the next. Errors are reported on Options.Stderr the way the command
+// line interpreter prints them, and returned.
+type Interpreter struct {
+	Globals *Environment
+	options Options
+	budget  *Budget
+	modules *Modules
+}
+
+// New creates an interpreter with a global environment holding only the
+// natives
+func New(options Options) *Interpreter {
+	if options.Stdout == nil {
+		options.Stdout = os.Stdout
+	}
+	if options.Stderr == nil {
+		options.Stderr = os.Stderr
+	}
+	interpreter := &Interpreter{options: options}
+	interpreter.modules = newModules(interpreter, options.Filename, options.SearchPath)
+	if options.Limits != (Limits{}) {
+		interpreter.budget = NewBudget(options.Limits)
+	}
+	interpreter.Globals = interpreter.newGlobals()
+	return interpreter
+}
+
+// newGlobals creates a global environment holding the natives and the math
+// namespace, for the program and for each module it imports
+func (i *Interpreter) newGlobals() *Environment {
+	globals := NewEnvironment()
+	globals.Stdout = i.options.Stdout
+	globals.Stderr = i.options.Stderr
+	globals.Budget = i.budget
+	globals.Modules = i.modules
+	globals.Define("math", newMathNamespace())
+	i.defineHostNatives(globals)
+	return globals
+}
+
+// Run parses and executes source, returning the value of its last
+// statement
+func (i *Interpreter) Run(ctx context.Context, source string) (Value, error) {
+	statements, err := i.Parse(source)
+	if err != nil {
+		return nil, err
+	}
+	return i.Execute(ctx, statements)
+}
+
+// Parse scans and parses a program. Every lexical error is reported and the
+// first one returned; parsing stops at the first syntax error.
+func (i *Interpreter) Parse(source string) ([]Stmt, error) {
+	scanner := NewLexer(source, false)
+	scanner.SetOutput(i.options.Stdout, i.options.Stderr)
+	scanner.Scan()
+	if errors := scanner.Errors(); len(errors) > 0 {
+		return nil, errors[0]
+	}
+
+	parser := NewParser(scanner, "run")
+	parser.SetMaxDepth(i.options.Limits.Nesting)
+	statements, errors := parser.ParseWithErrors()
+	if len(errors) > 0 {
+		fmt.Fprint(i.options.Stderr, errors[0].report)
+		return nil, errors[0]
+	}
+	if i.options.Optimize {
+		statements = Optimize(statements)
+	}
+	return statements, nil
+}
+
+// Execute runs parsed statements in the global environment and returns the
+// value of the last one, nil when that is Lox nil or a statement without a
+// value. A runtime error stops the program and is returned
+// as a *RuntimeError. So does cancelling ctx, with TimeoutExitCode or
+// CancelledExitCode, before the next statement or call starts. Exceeding
+// Options.Limits is a runtime error too.
+func (i *Interpreter) Execute(ctx context.Context, statements []Stmt) (value Value, err error) {
+	previous := i.Globals.Context
+	i.Globals.Context = ctx
+	if i.budget != nil {
+		i.budget.Reset()
+	}
+	defer func() {
+		i.Globals.Context = previous
+		if r := recover(); r != nil {
+			runtimeErr, ok := r.(*RuntimeError)
+			if !ok {
+				panic(r)
+			}
+			fmt.Fprint(i.options.Stderr, runtimeErr.report)
+			value, err = nil, runtimeErr
+		}
+	}()
+
+	for _, stmt := range statements {
+		value = ExecuteStatement(stmt, i.Globals)
+	}
+	if value == loxNil {
+		value = nil
+	}
+	return value, nil
+}
+
+// ExitCode returns the status the command line interpreter exits with after
+// an error returned by Run: 65 for syntax errors, the status of a runtime
+// error, and 0 without an error.
+func ExitCode(err error) int {
+	switch e := err.(type) {
+	case nil:
+		return 0
+	case LexError, ParseError:
+		return 65
+	case *RuntimeError:
+		return e.ExitCode
+	}
+	return 1
+}
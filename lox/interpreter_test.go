@@ -0,0 +1,162 @@
+package lox_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/codecrafters-io/interpreter-starter-go/lox"
+)
+
+// newWaitingInterpreter returns an interpreter with natives that block: wait
+// until the program is cancelled, and sleep for a while without looking at
+// the context
+func newWaitingInterpreter(t *testing.T, stdout io.Writer) *lox.Interpreter {
+	t.Helper()
+	interpreter := lox.New(lox.Options{Stdout: stdout, Stderr: io.Discard})
+	err := interpreter.Globals.Bind("wait", func(ctx context.Context, label string) (string, error) {
+		<-ctx.Done()
+		return label, ctx.Err()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = interpreter.Globals.Bind("sleep", func(milliseconds int) {
+		time.Sleep(time.Duration(milliseconds) * time.Millisecond)
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return interpreter
+}
+
+func TestTimeoutStopsBlockingNative(t *testing.T) {
+	interpreter := newWaitingInterpreter(t, io.Discard)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	_, err := interpreter.Run(ctx, `wait("forever");`)
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Errorf("the program stopped after %v", elapsed)
+	}
+	var runtimeErr *lox.RuntimeError
+	if !errors.As(err, &runtimeErr) || runtimeErr.Message != "Execution timed out." {
+		t.Fatalf("got %v, want a timeout", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("%v does not wrap context.DeadlineExceeded", err)
+	}
+	if code := lox.ExitCode(err); code != lox.TimeoutExitCode {
+		t.Errorf("exit code %d, want %d", code, lox.TimeoutExitCode)
+	}
+}
+
+func TestCancelStopsProgram(t *testing.T) {
+	interpreter := newWaitingInterpreter(t, io.Discard)
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		cancel()
+	}()
+
+	_, err := interpreter.Run(ctx, `wait("interrupt");`)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("got %v, want a cancelled program", err)
+	}
+	if code := lox.ExitCode(err); code != lox.CancelledExitCode {
+		t.Errorf("exit code %d, want %d", code, lox.CancelledExitCode)
+	}
+}
+
+func TestTimeoutStopsBeforeNextStatement(t *testing.T) {
+	var stdout bytes.Buffer
+	interpreter := newWaitingInterpreter(t, &stdout)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	// sleep ignores the context, so the program stops once it returns
+	_, err := interpreter.Run(ctx, "print 1;\nsleep(50);\nprint 2;")
+	var runtimeErr *lox.RuntimeError
+	if !errors.As(err, &runtimeErr) || runtimeErr.Message != "Execution timed out." || runtimeErr.Line != 3 {
+		t.Fatalf("got %v, want a timeout at line 3", err)
+	}
+	if got := stdout.String(); got != "1\n" {
+		t.Errorf("printed %q, want only the output before the timeout", got)
+	}
+}
+
+func TestContextParameterIsNotALoxArgument(t *testing.T) {
+	interpreter := newWaitingInterpreter(t, io.Discard)
+	_, err := interpreter.Run(context.Background(), `wait();`)
+	var runtimeErr *lox.RuntimeError
+	if !errors.As(err, &runtimeErr) || runtimeErr.Message != "Expected 1 arguments but got 0." {
+		t.Fatalf("got %v, want an arity error", err)
+	}
+}
+
+func TestOutputGoesToOptionsWriters(t *testing.T) {
+	tests := []struct {
+		source string
+		stdout string
+		stderr string
+	}{
+		{"print \"a\";\nprint 1 + 2;", "a\n3\n", ""},
+		{"print 1;\nprint @;", "", "[line 2] Error: Unexpected character: @\n"},
+		{"print 1;\nprint ;", "", "[line 2] Error at ';': Expected expression.\n"},
+		{"print 1;\nprint -\"a\";", "1\n", "Operands must be a number.\n[line 2]"},
+		{"import \"missing.lox\";", "", "Cannot find module 'missing.lox'.\n[line 1]\n"},
+	}
+
+	// Interpreters running side by side each write to their own buffers
+	type result struct{ stdout, stderr string }
+	results := make([]chan result, len(tests))
+	for i, test := range tests {
+		results[i] = make(chan result, 1)
+		go func(source string, results chan<- result) {
+			var stdout, stderr bytes.Buffer
+			interpreter := lox.New(lox.Options{Stdout: &stdout, Stderr: &stderr})
+			interpreter.Run(context.Background(), source)
+			results <- result{stdout.String(), stderr.String()}
+		}(test.source, results[i])
+	}
+	for i, test := range tests {
+		got := <-results[i]
+		if got.stdout != test.stdout {
+			t.Errorf("%q printed %q on stdout, want %q", test.source, got.stdout, test.stdout)
+		}
+		if got.stderr != test.stderr {
+			t.Errorf("%q printed %q on stderr, want %q", test.source, got.stderr, test.stderr)
+		}
+	}
+}
+
+func TestHooksSeeImportedModules(t *testing.T) {
+	dir := t.TempDir()
+	main := filepath.Join(dir, "main.lox")
+	module := filepath.Join(dir, "lib.lox")
+	if err := os.WriteFile(module, []byte("var x = 1;\n{\n  var y = 2;\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	interpreter := lox.New(lox.Options{Stdout: io.Discard, Stderr: io.Discard, Filename: main})
+	seen := []string{}
+	interpreter.Globals.Hooks.BeforeStmt = append(interpreter.Globals.Hooks.BeforeStmt, func(stmt lox.Stmt, env *lox.Environment) {
+		line, _ := lox.LineRange(stmt)
+		seen = append(seen, fmt.Sprintf("%s:%d", filepath.Base(env.File()), line))
+	})
+	if _, err := interpreter.Run(context.Background(), "import \"lib.lox\";\nprint 1;"); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"main.lox:1", "lib.lox:1", "lib.lox:2", "lib.lox:3", "main.lox:2"}
+	if !reflect.DeepEqual(seen, want) {
+		t.Errorf("hooks saw %v, want %v", seen, want)
+	}
+}
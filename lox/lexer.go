@@ -1,8 +1,10 @@
-package main
+package lox
 
 import (
 	"fmt"
+	"io"
 	"os"
+	"strings"
 	"unicode"
 )
 
@@ -20,24 +22,70 @@ For the source code `var x = 10`:
 - Token 4: {Type: "NUMBER", Lexeme: "10", Literal: "10", Line: 1}
 */
 
-// Token structure to represent each token in the source code
+// Token structure to represent each token in the source code. Line, Column
+// and Offset are where the token starts. A newline inside a string literal
+// does not count as a line, so that errors keep the lines they always had,
+// but columns count characters from 1 after the last newline of any kind.
+// Offset is in bytes from the start of the source.
 type Token struct {
 	Type    string
 	Lexeme  string
 	Literal string
 	Line    int
+	Column  int
+	Offset  int
+}
+
+// String formats the token the way the tokenize command prints it
+func (t Token) String() string {
+	switch t.Type {
+	case "STRING":
+		return fmt.Sprintf("STRING \"%s\" %s", t.Lexeme, t.Literal)
+	case "NUMBER":
+		return fmt.Sprintf("NUMBER %s %s", t.Lexeme, t.Literal)
+	}
+	return fmt.Sprintf("%s %s null", t.Type, t.Lexeme)
+}
+
+// LexError is a lexical error with the position it was found at
+type LexError struct {
+	Line    int
+	Column  int
+	Offset  int
+	Message string
+}
+
+// Error formats the error the way it is reported on stderr
+func (e LexError) Error() string {
+	return fmt.Sprintf("[line %d] Error: %s", e.Line, e.Message)
+}
+
+// Comment is a // comment skipped by the lexer, kept for tools like fmt
+type Comment struct {
+	Text   string
+	Line   int
+	Column int
 }
 
 // Lexer structure to maintain the state of lexical analysis
 type Lexer struct {
 	source      string
 	line        int
-	errors      []string
+	lineStart   int // position of the first character of the current line
+	start       int // position of the first character of the current token
+	startLine   int // line and column where the current token starts
+	startColumn int
+	counted     int // position up to which the characters of the line are counted
+	characters  int // characters of the current line before counted
+	errors      []LexError
 	position    int
 	nextPosition int
 	ch          byte
 	tokens      []Token
+	comments    []Comment
 	logEnabled  bool // New field to control logging
+	stdout      io.Writer // receives the token log
+	stderr      io.Writer // receives lexical and syntax errors
 }
 
 // NewLexer initializes a new lexer for the given source code
@@ -45,14 +93,24 @@ func NewLexer(source string, logEnabled bool) *Lexer {
 	l := &Lexer{
 		source:     source,
 		line:       1,
-		errors:     []string{},
+		errors:     []LexError{},
 		tokens:     []Token{},
 		logEnabled: logEnabled, // Set logEnabled
+		stdout:     os.Stdout,
+		stderr:     os.Stderr,
 	}
 	l.readChar()
 	return l
 }
 
+// SetOutput sets where the token log and errors are written, by default
+// os.Stdout and os.Stderr. A parser reading the lexer reports its errors to
+// the same stderr.
+func (l *Lexer) SetOutput(stdout, stderr io.Writer) {
+	l.stdout = stdout
+	l.stderr = stderr
+}
+
 // readChar reads the next character and updates position in the source
 func (l *Lexer) readChar() {
 	if l.nextPosition >= len(l.source) {
@@ -64,84 +122,85 @@ func (l *Lexer) readChar() {
 	l.nextPosition++
 }
 
-// ScanTokens processes the source and generates tokens
-func (l *Lexer) ScanTokens() {
+// ScanTokens processes the source and generates tokens, returning the first
+// lexical error. Every error is reported on stderr as it is found.
+func (l *Lexer) ScanTokens() error {
+	l.Scan()
+
+	if len(l.errors) > 0 {
+		return l.errors[0]
+	}
+	return nil
+}
+
+// Scan processes the source and generates tokens, collecting errors
+func (l *Lexer) Scan() {
 	for l.ch != 0 {
+		l.start = l.position
+		l.startLine = l.line
+		l.startColumn = l.column(l.position)
 		switch {
 		case l.isDigit():
 			l.handleNumberLiteral()
 		case l.ch == '(':
 			l.addToken("LEFT_PAREN", "(", "")
-			l.log("LEFT_PAREN ( null")
 		case l.ch == ')':
 			l.addToken("RIGHT_PAREN", ")", "")
-			l.log("RIGHT_PAREN ) null")
 		case l.ch == '{':
 			l.addToken("LEFT_BRACE", "{", "")
-			l.log("LEFT_BRACE { null")
 		case l.ch == '}':
 			l.addToken("RIGHT_BRACE", "}", "")
-			l.log("RIGHT_BRACE } null")
+		case l.ch == '[':
+			l.addToken("LEFT_BRACKET", "[", "")
+		case l.ch == ']':
+			l.addToken("RIGHT_BRACKET", "]", "")
+		case l.ch == ':':
+			l.addToken("COLON", ":", "")
 		case l.ch == '*':
 			l.addToken("STAR", "*", "")
-			l.log("STAR * null")
 		case l.ch == '.':
 			l.addToken("DOT", ".", "")
-			l.log("DOT . null")
 		case l.ch == ',':
 			l.addToken("COMMA", ",", "")
-			l.log("COMMA , null")
 		case l.ch == '+':
 			l.addToken("PLUS", "+", "")
-			l.log("PLUS + null")
 		case l.ch == '-':
 			l.addToken("MINUS", "-", "")
-			l.log("MINUS - null")
 		case l.ch == ';':
 			l.addToken("SEMICOLON", ";", "")
-			l.log("SEMICOLON ; null")
 		case l.ch == '=':
 			if l.peekChar() == '=' {
 				l.addToken("EQUAL_EQUAL", "==", "")
-				l.log("EQUAL_EQUAL == null")
 				l.readChar()
 			} else {
 				l.addToken("EQUAL", "=", "")
-				l.log("EQUAL = null")
 			}
 		case l.ch == '!':
 			if l.peekChar() == '=' {
 				l.addToken("BANG_EQUAL", "!=", "")
-				l.log("BANG_EQUAL != null")
 				l.readChar()
 			} else {
 				l.addToken("BANG", "!", "")
-				l.log("BANG ! null")
 			}
 		case l.ch == '<':
 			if l.peekChar() == '=' {
 				l.addToken("LESS_EQUAL", "<=", "")
-				l.log("LESS_EQUAL <= null")
 				l.readChar()
 			} else {
 				l.addToken("LESS", "<", "")
-				l.log("LESS < null")
 			}
 		case l.ch == '>':
 			if l.peekChar() == '=' {
 				l.addToken("GREATER_EQUAL", ">=", "")
-				l.log("GREATER_EQUAL >= null")
 				l.readChar()
 			} else {
 				l.addToken("GREATER", ">", "")
-				l.log("GREATER > null")
 			}
 		case l.ch == '/':
 			if l.peekChar() == '/' {
 				l.skipComment()
 			} else {
 				l.addToken("SLASH", "/", "")
-				l.log("SLASH / null")
 			}
 		case l.ch == '"':
 			l.handleStringLiteral()
@@ -152,7 +211,7 @@ func (l *Lexer) ScanTokens() {
 			}
 			if l.isWhitespace() {
 				if l.ch == '\n' {
-					l.line++
+					l.newLine()
 				}
 				l.readChar()
 				continue
@@ -161,11 +220,49 @@ func (l *Lexer) ScanTokens() {
 		}
 		l.readChar()
 	}
-	l.log("EOF  null")
+	l.log(l.EOF().String())
+}
 
-	if len(l.errors) > 0 {
-		os.Exit(65)
+// Tokens returns the scanned tokens
+func (l *Lexer) Tokens() []Token {
+	return l.tokens
+}
+
+// Comments returns the comments found while scanning
+func (l *Lexer) Comments() []Comment {
+	return l.comments
+}
+
+// Errors returns the lexical errors found while scanning
+func (l *Lexer) Errors() []LexError {
+	return l.errors
+}
+
+// EOF returns the end of file token, which is printed but not stored
+func (l *Lexer) EOF() Token {
+	return Token{Type: "EOF", Line: l.line, Column: l.column(len(l.source)), Offset: len(l.source)}
+}
+
+// column returns the column of a position on the current line, counting
+// characters rather than bytes. Positions only move forward, so the count
+// carries on from the previous call.
+func (l *Lexer) column(position int) int {
+	if l.counted < l.lineStart {
+		l.counted = l.lineStart
+		l.characters = 0
+	}
+	for ; l.counted < position; l.counted++ {
+		if l.source[l.counted]&0xC0 != 0x80 { // not a UTF-8 continuation byte
+			l.characters++
+		}
 	}
+	return l.characters + 1
+}
+
+// newLine records that the current character ends a line
+func (l *Lexer) newLine() {
+	l.line++
+	l.lineStart = l.position + 1
 }
 
 // addToken creates a new token and appends it to the token list
@@ -174,15 +271,18 @@ func (l *Lexer) addToken(tokenType, lexeme, literal string) {
 		Type:    tokenType,
 		Lexeme:  lexeme,
 		Literal: literal,
-		Line:    l.line,
+		Line:    l.startLine,
+		Column:  l.startColumn,
+		Offset:  l.start,
 	}
 	l.tokens = append(l.tokens, token)
+	l.log(token.String())
 }
 
 // log prints only if logging is enabled
 func (l *Lexer) log(message string) {
 	if l.logEnabled {
-		fmt.Println(message)
+		fmt.Fprintln(l.stdout, message)
 	}
 }
 
@@ -197,10 +297,8 @@ func (l *Lexer) handleIdentifier() {
 	keyword, ok := RESERVED_WORDS[identifier]
 	if !ok {
 		l.addToken("IDENTIFIER", identifier, "")
-		l.log(fmt.Sprintf("IDENTIFIER %s null", identifier))
 	} else {
 		l.addToken(keyword, identifier, "")
-		l.log(fmt.Sprintf("%s %s null", keyword, identifier))
 	}
 }
 
@@ -212,11 +310,12 @@ func (l *Lexer) handleStringLiteral() {
 		if l.ch == '"' {
 			literal := l.source[startPosition+1 : l.position]
 			l.addToken("STRING", literal, literal)
-			l.log(fmt.Sprintf("STRING \"%s\" %s", literal, literal))
 			return
 		} else if l.ch == 0 {
 			l.reportErrorUnterminatedString()
 			return
+		} else if l.ch == '\n' {
+			l.lineStart = l.position + 1 // Columns restart, the line count does not
 		}
 	}
 }
@@ -236,7 +335,6 @@ func (l *Lexer) handleNumberLiteral() {
 
 	literal := l.source[startPosition:l.position]
 	l.addToken("NUMBER", literal, formatAsFloat(literal))
-	l.log(fmt.Sprintf("NUMBER %s %s", literal, formatAsFloat(literal)))
 	l.position--
 	l.nextPosition--
 }
@@ -246,8 +344,13 @@ func (l *Lexer) skipComment() {
 	for l.ch != '\n' && l.ch != 0 {
 		l.readChar()
 	}
+	l.comments = append(l.comments, Comment{
+		Text:   strings.TrimRight(l.source[l.start:l.position], " \t\r"),
+		Line:   l.startLine,
+		Column: l.startColumn,
+	})
 	if l.ch == '\n' {
-		l.line++
+		l.newLine()
 	}
 }
 
@@ -280,15 +383,18 @@ func (l *Lexer) isDigitAtNextPosition() bool {
 
 // Error handling
 func (l *Lexer) reportErrorUnterminatedString() {
-	errorMessage := fmt.Sprintf("[line %d] Error: Unterminated string.", l.line)
-	fmt.Fprintln(os.Stderr, errorMessage)
-	l.errors = append(l.errors, errorMessage)
+	l.addError("Unterminated string.")
 }
 
 func (s *Lexer) reportError(content byte) {
-	errorMessage := fmt.Sprintf("[line %d] Error: Unexpected character: %c", s.line, content)
-	fmt.Fprintln(os.Stderr, errorMessage)
-	s.errors = append(s.errors, errorMessage)
+	s.addError(fmt.Sprintf("Unexpected character: %c", content))
+}
+
+// addError reports an error at the start of the current token
+func (l *Lexer) addError(message string) {
+	lexError := LexError{Line: l.startLine, Column: l.startColumn, Offset: l.start, Message: message}
+	fmt.Fprintln(l.stderr, lexError.Error())
+	l.errors = append(l.errors, lexError)
 }
 
 
@@ -0,0 +1,44 @@
+package lox_test
+
+import (
+	"io"
+	"testing"
+
+	"github.com/codecrafters-io/interpreter-starter-go/lox"
+)
+
+func TestTokenPositionsKeepLinesAndCountColumnsFromLastNewline(t *testing.T) {
+	source := "var s = \"a\nbc\"; print s;\n\"ü\" + x; @"
+	lexer := lox.NewLexer(source, false)
+	lexer.SetOutput(io.Discard, io.Discard)
+	lexer.Scan()
+
+	type position struct {
+		lexeme       string
+		line, column int
+	}
+	want := []position{
+		{"var", 1, 1}, {"s", 1, 5}, {"=", 1, 7}, {"a\nbc", 1, 9}, {";", 1, 4},
+		{"print", 1, 6}, {"s", 1, 12}, {";", 1, 13},
+		{"ü", 2, 1}, {"+", 2, 5}, {"x", 2, 7}, {";", 2, 8},
+	}
+	// A newline inside a string restarts the columns but, as it always did,
+	// not the line count
+	tokens := lexer.Tokens()
+	if len(tokens) != len(want) {
+		t.Fatalf("got %d tokens, want %d", len(tokens), len(want))
+	}
+	for i, token := range tokens {
+		if got := (position{token.Lexeme, token.Line, token.Column}); got != want[i] {
+			t.Errorf("token %d at %+v, want %+v", i, got, want[i])
+		}
+	}
+
+	errors := lexer.Errors()
+	if len(errors) != 1 || errors[0].Line != 2 || errors[0].Column != 10 || errors[0].Offset != len(source)-1 {
+		t.Errorf("got errors %+v, want one at line 2, column 10", errors)
+	}
+	if eof := lexer.EOF(); eof.Line != 2 || eof.Column != 11 {
+		t.Errorf("EOF at %d:%d, want 2:11", eof.Line, eof.Column)
+	}
+}
@@ -0,0 +1,125 @@
+// limits.go
+package lox
+
+import "fmt"
+
+// Limits cap the resources a program may use, so untrusted scripts cannot
+// hang or exhaust the host. Exceeding a limit is a runtime error. Zero means
+// no limit.
+type Limits struct {
+	Steps       int // statements and expressions evaluated
+	CallDepth   int // calls in progress at once, reported as "Stack overflow."
+	StringBytes int // bytes of all strings built by concatenation
+	Objects     int // lists, maps and Go struct copies created
+	Nesting     int // expressions and blocks nested inside each other, MaxNesting when zero
+}
+
+// MaxNesting is how deeply expressions and blocks may nest when Limits do
+// not lower it. Deeper programs would overflow the Go stack of the parser
+// and the evaluator, so the parser reports them as a syntax error.
+const MaxNesting = 10000
+
+// nesting returns the nesting limit that applies
+func (l Limits) nesting() int {
+	if l.Nesting > 0 && l.Nesting < MaxNesting {
+		return l.Nesting
+	}
+	return MaxNesting
+}
+
+// Budget is what a program has used of its Limits. It is shared by every
+// nested environment and reset by Interpreter.Execute.
+type Budget struct {
+	Limits      Limits
+	Steps       int
+	CallDepth   int
+	StringBytes int
+	Objects     int
+	Nesting     int
+}
+
+// NewBudget creates an unused budget for limits
+func NewBudget(limits Limits) *Budget {
+	return &Budget{Limits: limits}
+}
+
+// Reset forgets what has been used
+func (b *Budget) Reset() {
+	*b = Budget{Limits: b.Limits}
+}
+
+// step counts an evaluated statement or expression
+func (b *Budget) step(node Expr) {
+	b.Steps++
+	if b.Limits.Steps > 0 && b.Steps > b.Limits.Steps {
+		line, _ := LineRange(node)
+		raiseError(line, fmt.Sprintf("Step limit of %d exceeded.", b.Limits.Steps))
+	}
+}
+
+// enterCall counts a call in progress until exitCall
+func (b *Budget) enterCall(line int) {
+	b.CallDepth++
+	if b.Limits.CallDepth > 0 && b.CallDepth > b.Limits.CallDepth {
+		raiseError(line, "Stack overflow.")
+	}
+}
+
+func (b *Budget) exitCall() {
+	b.CallDepth--
+}
+
+// enterNode counts a block or expression being evaluated inside others
+// until exitNode
+func (b *Budget) enterNode(node Expr) {
+	b.Nesting++
+	if b.Nesting > b.Limits.nesting() {
+		line, _ := LineRange(node)
+		raiseError(line, fmt.Sprintf("Nesting limit of %d exceeded.", b.Limits.nesting()))
+	}
+}
+
+func (b *Budget) exitNode() {
+	b.Nesting--
+}
+
+// allocateString counts the bytes of a string about to be built
+func (b *Budget) allocateString(line int, size int) {
+	b.StringBytes += size
+	if b.Limits.StringBytes > 0 && b.StringBytes > b.Limits.StringBytes {
+		raiseError(line, fmt.Sprintf("String memory limit of %d bytes exceeded.", b.Limits.StringBytes))
+	}
+}
+
+// allocateObject counts a new object. It returns an error rather than
+// raising one, so natives creating objects can return it to be reported at
+// their call. The budget may be nil.
+func (b *Budget) allocateObject() error {
+	if b == nil {
+		return nil
+	}
+	b.Objects++
+	if b.Limits.Objects > 0 && b.Objects > b.Limits.Objects {
+		return fmt.Errorf("Object limit of %d exceeded.", b.Limits.Objects)
+	}
+	return nil
+}
+
+// newList creates a list for a script, counted as an object. The lists its
+// methods create are counted against the same budget.
+func (b *Budget) newList(elements []Value) (*List, error) {
+	if err := b.allocateObject(); err != nil {
+		return nil, err
+	}
+	return &List{Elements: elements, budget: b}, nil
+}
+
+// newMap creates an empty map for a script, counted as an object
+func (b *Budget) newMap() (*Map, error) {
+	if err := b.allocateObject(); err != nil {
+		return nil, err
+	}
+	result := NewMap()
+	result.budget = b
+	return result, nil
+}
@@ -0,0 +1,149 @@
+package lox_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/codecrafters-io/interpreter-starter-go/lox"
+)
+
+func TestCallDepthLimit(t *testing.T) {
+	interpreter := lox.New(lox.Options{Stderr: io.Discard, Limits: lox.Limits{CallDepth: 5}})
+	statements, err := interpreter.Parse("recurse();")
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := statements[0].(*lox.ExpressionStatement).Expression
+
+	// A host function calling back into Lox is the only way to nest calls
+	calls := 0
+	err = interpreter.Globals.Bind("recurse", func() error {
+		calls++
+		_, err := lox.Evaluate(call, interpreter.Globals)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	_, err = interpreter.Execute(context.Background(), statements)
+	var runtimeErr *lox.RuntimeError
+	if !errors.As(err, &runtimeErr) || runtimeErr.Message != "Stack overflow." {
+		t.Fatalf("got %v, want a stack overflow", err)
+	}
+	if calls != 5 {
+		t.Errorf("recurse ran %d times, want 5", calls)
+	}
+}
+
+func TestCallDepthIsReleasedByRecoveredErrors(t *testing.T) {
+	interpreter := lox.New(lox.Options{Stderr: io.Discard, Limits: lox.Limits{CallDepth: 3}})
+	statements, err := interpreter.Parse("fail();")
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := statements[0].(*lox.ExpressionStatement).Expression
+
+	// try calls a failing function and swallows its error, like a host
+	// retrying an operation would
+	err = interpreter.Globals.Bind("fail", func() error { return errors.New("failed") })
+	if err == nil {
+		err = interpreter.Globals.Bind("try", func() {
+			lox.Evaluate(call, interpreter.Globals)
+		})
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := interpreter.Run(context.Background(), "try(); try(); try(); try(); try();"); err != nil {
+		t.Errorf("got %v after recovered errors, want no error", err)
+	}
+}
+
+func TestNestingLimitInParser(t *testing.T) {
+	const depth = 300000
+	sources := []string{
+		strings.Repeat("(", depth) + "1" + strings.Repeat(")", depth) + ";",
+		strings.Repeat("-", depth) + "1;",
+		strings.Repeat("[", depth) + strings.Repeat("]", depth) + ";",
+		strings.Repeat("{", depth) + strings.Repeat("}", depth),
+		"var a;" + strings.Repeat("a = ", depth) + "1;",
+	}
+	for _, source := range sources {
+		interpreter := lox.New(lox.Options{Stderr: io.Discard})
+		_, err := interpreter.Run(context.Background(), source)
+		var parseErr lox.ParseError
+		if !errors.As(err, &parseErr) || parseErr.Message != "Nesting limit of 10000 exceeded." {
+			t.Errorf("%.10s...: got %v, want a nesting syntax error", source, err)
+		}
+	}
+
+	interpreter := lox.New(lox.Options{Stderr: io.Discard, Limits: lox.Limits{Nesting: 3}})
+	if _, err := interpreter.Run(context.Background(), "((1));"); err != nil {
+		t.Errorf("nesting within the limit: %v", err)
+	}
+	if _, err := interpreter.Run(context.Background(), "(((1)));"); lox.ExitCode(err) != 65 {
+		t.Errorf("nesting beyond the limit: got %v, want a syntax error", err)
+	}
+}
+
+func TestNestingLimitInEvaluator(t *testing.T) {
+	// Syntax trees built by a host do not go through the parser
+	var expr lox.Expr = &lox.Literal{Value: "1", Type: "number", Line: 1}
+	for i := 0; i < 20; i++ {
+		expr = &lox.Grouping{Expression: expr, Line: 1}
+	}
+	statements := []lox.Stmt{&lox.ExpressionStatement{Expression: expr, Line: 1}}
+
+	interpreter := lox.New(lox.Options{Stderr: io.Discard, Limits: lox.Limits{Nesting: 10}})
+	_, err := interpreter.Execute(context.Background(), statements)
+	var runtimeErr *lox.RuntimeError
+	if !errors.As(err, &runtimeErr) || runtimeErr.Message != "Nesting limit of 10 exceeded." {
+		t.Fatalf("got %v, want a nesting runtime error", err)
+	}
+
+	interpreter = lox.New(lox.Options{Stderr: io.Discard, Limits: lox.Limits{Nesting: 30}})
+	if value, err := interpreter.Execute(context.Background(), statements); err != nil || value != 1.0 {
+		t.Errorf("got %v, %v, want 1", value, err)
+	}
+}
+
+func TestObjectLimitCountsCreatedObjects(t *testing.T) {
+	shared := &point{X: 1}
+	tests := []struct {
+		source string
+		want   string // runtime error, "" for none
+	}{
+		// Taking lists out of a list or a shared Go value allocates nothing
+		{`var xs = [[1], [2]]; xs.pop(); xs.pop(); xs.push(1); xs.push(1);`, ""},
+		{`same(); same(); same(); same(); same();`, ""},
+		{`var xs = [1, 2]; xs.slice(1); xs.slice(1); xs.slice(1);`, "Object limit of 3 exceeded."},
+		{`var m = {"a": 1}; m.keys(); m.values(); m.keys();`, "Object limit of 3 exceeded."},
+		{`"a,b".split(","); "a,b".split(","); "a,b".split(","); "a,b".split(",");`, "Object limit of 3 exceeded."},
+		{`fresh(); fresh();`, "Object limit of 3 exceeded."},
+		{`copied(); copied(); copied(); copied();`, "Object limit of 3 exceeded."},
+	}
+	for _, test := range tests {
+		interpreter := lox.New(lox.Options{Stderr: io.Discard, Limits: lox.Limits{Objects: 3}})
+		interpreter.Globals.Bind("same", func() *point { return shared })
+		interpreter.Globals.Bind("fresh", func() [][]int { return [][]int{{1}} })
+		interpreter.Globals.Bind("copied", func() point { return *shared })
+
+		_, err := interpreter.Run(context.Background(), test.source)
+		got := ""
+		var runtimeErr *lox.RuntimeError
+		if errors.As(err, &runtimeErr) {
+			got = runtimeErr.Message
+		} else if err != nil {
+			t.Errorf("%s: %v", test.source, err)
+			continue
+		}
+		if got != test.want {
+			t.Errorf("%s: got error %q, want %q", test.source, got, test.want)
+		}
+	}
+}
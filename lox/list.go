@@ -0,0 +1,175 @@
+// list.go
+package lox
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// List is a mutable sequence of values, created by a list literal such as
+// [1, 2, 3]. Its elements are read and written with xs[i], where a negative
+// index counts from the end, and it has the methods push, pop, insert,
+// remove, len and slice.
+type List struct {
+	Elements []Value
+	budget   *Budget // counts the lists made by slice, nil for lists made by the host
+}
+
+// NewList creates a list holding elements
+func NewList(elements []Value) *List {
+	return &List{Elements: elements}
+}
+
+// index checks an index read from a script and returns it counted from the
+// start. size is one more than the last valid index, which lets insert add
+// at the end.
+func (l *List) index(value Value, size int) (int, error) {
+	number, ok := toNumber(value)
+	if !ok || number != math.Trunc(number) || math.Abs(number) > math.MaxInt32 {
+		return 0, fmt.Errorf("List index must be an integer, got %s.", formatValue(value))
+	}
+	index := int(number)
+	if index < 0 {
+		index += len(l.Elements)
+	}
+	if index < 0 || index >= size {
+		return 0, fmt.Errorf("List index %d is out of range for length %d.", int(number), len(l.Elements))
+	}
+	return index, nil
+}
+
+// At returns the element at an index
+func (l *List) At(index Value) (Value, error) {
+	i, err := l.index(index, len(l.Elements))
+	if err != nil {
+		return nil, err
+	}
+	return l.Elements[i], nil
+}
+
+// SetAt replaces the element at an index
+func (l *List) SetAt(index Value, value Value) error {
+	i, err := l.index(index, len(l.Elements))
+	if err != nil {
+		return err
+	}
+	l.Elements[i] = value
+	return nil
+}
+
+func (l *List) Get(name string) (Value, error) {
+	method := func(params int, function func(arguments []Value) (Value, error)) *NativeFunction {
+		return &NativeFunction{Name: name, Params: params, Function: function}
+	}
+
+	switch name {
+	case "len":
+		return method(0, func([]Value) (Value, error) {
+			return float64(len(l.Elements)), nil
+		}), nil
+	case "push":
+		return method(1, func(arguments []Value) (Value, error) {
+			l.Elements = append(l.Elements, arguments[0])
+			return loxNil, nil
+		}), nil
+	case "pop":
+		return method(0, func([]Value) (Value, error) {
+			if len(l.Elements) == 0 {
+				return nil, fmt.Errorf("Cannot pop from an empty list.")
+			}
+			last := l.Elements[len(l.Elements)-1]
+			l.Elements = l.Elements[:len(l.Elements)-1]
+			return last, nil
+		}), nil
+	case "insert":
+		// Inserts before the element at index; the length appends
+		return method(2, func(arguments []Value) (Value, error) {
+			i, err := l.index(arguments[0], len(l.Elements)+1)
+			if err != nil {
+				return nil, err
+			}
+			l.Elements = append(l.Elements, nil)
+			copy(l.Elements[i+1:], l.Elements[i:])
+			l.Elements[i] = arguments[1]
+			return loxNil, nil
+		}), nil
+	case "remove":
+		// Removes the element at index and returns it
+		return method(1, func(arguments []Value) (Value, error) {
+			i, err := l.index(arguments[0], len(l.Elements))
+			if err != nil {
+				return nil, err
+			}
+			removed := l.Elements[i]
+			l.Elements = append(l.Elements[:i], l.Elements[i+1:]...)
+			return removed, nil
+		}), nil
+	case "slice":
+		// A new list of the elements from start up to but not including
+		// end, which defaults to the length. Both may be negative.
+		return method(-1, func(arguments []Value) (Value, error) {
+			if len(arguments) < 1 || len(arguments) > 2 {
+				return nil, fmt.Errorf("Expected 1 or 2 arguments but got %d.", len(arguments))
+			}
+			bounds := []int{0, len(l.Elements)}
+			for index, argument := range arguments {
+				bound, err := l.index(argument, len(l.Elements)+1)
+				if err != nil {
+					return nil, err
+				}
+				bounds[index] = bound
+			}
+			if bounds[0] > bounds[1] {
+				return nil, fmt.Errorf("Slice start %d is after its end %d.", bounds[0], bounds[1])
+			}
+			return l.budget.newList(append([]Value{}, l.Elements[bounds[0]:bounds[1]]...))
+		}), nil
+	}
+	return nil, fmt.Errorf("Undefined property '%s'.", name)
+}
+
+func (l *List) Set(name string, value Value) error {
+	return fmt.Errorf("Cannot assign to '%s' of a list.", name)
+}
+
+// String formats the list the way print shows it: [1, "two", [3]]
+func (l *List) String() string {
+	return formatValue(l)
+}
+
+// formatValue formats a value the way it appears inside a collection:
+// strings are quoted, and a collection containing itself is shown as [...]
+// or {...}
+func formatValue(value Value) string {
+	return formatNested(value, map[interface{}]bool{})
+}
+
+func formatNested(value Value, visiting map[interface{}]bool) string {
+	switch v := value.(type) {
+	case nil, nilValue:
+		return "nil"
+	case string:
+		return strconv.Quote(v)
+	case *List:
+		if visiting[v] {
+			return "[...]"
+		}
+		visiting[v] = true
+		defer delete(visiting, v)
+		elements := []string{}
+		for _, element := range v.Elements {
+			elements = append(elements, formatNested(element, visiting))
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	case *Map:
+		if visiting[v] {
+			return "{...}"
+		}
+		visiting[v] = true
+		defer delete(visiting, v)
+		return v.formatEntries(visiting)
+	}
+	return Stringify(value)
+}
@@ -0,0 +1,137 @@
+// map.go
+package lox
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// Map is a mutable dictionary, created by a map literal such as
+// {"a": 1, "b": 2}. Its values are read and written with m[key] and it has
+// the methods keys, values, has, remove and len. Keys must be strings,
+// numbers, booleans or nil, and keep the order they were first added in.
+type Map struct {
+	keys    []Value
+	entries map[Value]Value
+	budget  *Budget // counts the lists made by keys and values, nil for maps made by the host
+}
+
+// NewMap creates an empty map
+func NewMap() *Map {
+	return &Map{keys: []Value{}, entries: map[Value]Value{}}
+}
+
+// mapKey checks that a value can be a key and normalizes it, so that equal
+// numbers are the same key
+func mapKey(key Value) (Value, error) {
+	switch k := key.(type) {
+	case nil, nilValue:
+		return loxNil, nil
+	case int:
+		return float64(k), nil
+	case float64:
+		if math.IsNaN(k) {
+			return nil, fmt.Errorf("Map key cannot be NaN.")
+		}
+		return k, nil
+	case string, bool:
+		return k, nil
+	}
+	return nil, fmt.Errorf("Map key must be a string, number, boolean or nil, got %s.", typeName(key))
+}
+
+// At returns the value of a key
+func (m *Map) At(key Value) (Value, error) {
+	k, err := mapKey(key)
+	if err != nil {
+		return nil, err
+	}
+	value, ok := m.entries[k]
+	if !ok {
+		return nil, fmt.Errorf("Undefined key %s.", formatValue(key))
+	}
+	return value, nil
+}
+
+// SetAt adds a key or replaces its value
+func (m *Map) SetAt(key Value, value Value) error {
+	k, err := mapKey(key)
+	if err != nil {
+		return err
+	}
+	if _, ok := m.entries[k]; !ok {
+		m.keys = append(m.keys, k)
+	}
+	m.entries[k] = value
+	return nil
+}
+
+func (m *Map) Get(name string) (Value, error) {
+	method := func(params int, function func(arguments []Value) (Value, error)) *NativeFunction {
+		return &NativeFunction{Name: name, Params: params, Function: function}
+	}
+
+	switch name {
+	case "len":
+		return method(0, func([]Value) (Value, error) {
+			return float64(len(m.keys)), nil
+		}), nil
+	case "keys":
+		return method(0, func([]Value) (Value, error) {
+			return m.budget.newList(append([]Value{}, m.keys...))
+		}), nil
+	case "values":
+		return method(0, func([]Value) (Value, error) {
+			values := []Value{}
+			for _, key := range m.keys {
+				values = append(values, m.entries[key])
+			}
+			return m.budget.newList(values)
+		}), nil
+	case "has":
+		return method(1, func(arguments []Value) (Value, error) {
+			k, err := mapKey(arguments[0])
+			if err != nil {
+				return nil, err
+			}
+			_, ok := m.entries[k]
+			return ok, nil
+		}), nil
+	case "remove":
+		// Removes a key and returns its value
+		return method(1, func(arguments []Value) (Value, error) {
+			value, err := m.At(arguments[0])
+			if err != nil {
+				return nil, err
+			}
+			k, _ := mapKey(arguments[0])
+			delete(m.entries, k)
+			for i, key := range m.keys {
+				if key == k {
+					m.keys = append(m.keys[:i], m.keys[i+1:]...)
+					break
+				}
+			}
+			return value, nil
+		}), nil
+	}
+	return nil, fmt.Errorf("Undefined property '%s'.", name)
+}
+
+func (m *Map) Set(name string, value Value) error {
+	return fmt.Errorf("Cannot assign to '%s' of a map.", name)
+}
+
+// String formats the map the way print shows it: {"a": 1, "b": [2]}
+func (m *Map) String() string {
+	return formatValue(m)
+}
+
+func (m *Map) formatEntries(visiting map[interface{}]bool) string {
+	entries := []string{}
+	for _, key := range m.keys {
+		entries = append(entries, formatNested(key, visiting)+": "+formatNested(m.entries[key], visiting))
+	}
+	return "{" + strings.Join(entries, ", ") + "}"
+}
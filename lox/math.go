@@ -0,0 +1,104 @@
+// math.go
+package lox
+
+import (
+	"fmt"
+	"math"
+	"strings"
+)
+
+// newMathNamespace creates the math namespace, natives over Go's math
+// package:
+//
+//	print math.sqrt(2) * math.pi;
+//
+// A function that would return NaN or an infinity for finite arguments,
+// such as math.sqrt(-1), math.log(0) or math.exp(1000), raises a domain
+// error instead. Passing math.inf or math.nan is how a script asks for those
+// values, which then propagate as usual. They print as inf, -inf and nan.
+func newMathNamespace() *Namespace {
+	members := map[string]Value{
+		"pi":  math.Pi,
+		"e":   math.E,
+		"inf": math.Inf(1),
+		"nan": math.NaN(),
+	}
+
+	unary := map[string]func(float64) float64{
+		"sqrt":  math.Sqrt,
+		"abs":   math.Abs,
+		"floor": math.Floor,
+		"ceil":  math.Ceil,
+		"round": math.Round,
+		"sin":   math.Sin,
+		"cos":   math.Cos,
+		"tan":   math.Tan,
+		"asin":  math.Asin,
+		"acos":  math.Acos,
+		"atan":  math.Atan,
+		"exp":   math.Exp,
+		"log":   math.Log,
+		"log2":  math.Log2,
+		"log10": math.Log10,
+	}
+	for name, function := range unary {
+		members[name] = mathFunction(name, 1, func(x []float64) float64 { return function(x[0]) })
+	}
+	members["pow"] = mathFunction("pow", 2, func(x []float64) float64 { return math.Pow(x[0], x[1]) })
+	members["atan2"] = mathFunction("atan2", 2, func(x []float64) float64 { return math.Atan2(x[0], x[1]) })
+	members["min"] = mathFunction("min", -1, func(x []float64) float64 { return reduce(x, math.Min) })
+	members["max"] = mathFunction("max", -1, func(x []float64) float64 { return reduce(x, math.Max) })
+
+	return &Namespace{Name: "math", Members: members}
+}
+
+// mathFunction wraps a function of numbers as a native taking params
+// arguments, or at least one when params is -1
+func mathFunction(name string, params int, function func([]float64) float64) *NativeFunction {
+	return &NativeFunction{Name: name, Params: params, Function: func(arguments []Value) (Value, error) {
+		if len(arguments) == 0 {
+			return nil, fmt.Errorf("Expected at least 1 arguments but got 0.")
+		}
+		numbers := []float64{}
+		finite := true
+		for index := range arguments {
+			number, err := numberArgument(name, arguments, index)
+			if err != nil {
+				return nil, err
+			}
+			numbers = append(numbers, number)
+			finite = finite && !math.IsNaN(number) && !math.IsInf(number, 0)
+		}
+
+		result := function(numbers)
+		if finite && (math.IsNaN(result) || math.IsInf(result, 0)) {
+			formatted := []string{}
+			for _, argument := range arguments {
+				formatted = append(formatted, Stringify(argument))
+			}
+			problem := "is not a number"
+			if math.IsInf(result, 0) {
+				problem = "is not finite"
+			}
+			return nil, fmt.Errorf("Math domain error: %s(%s) %s.", name, strings.Join(formatted, ", "), problem)
+		}
+		return result, nil
+	}}
+}
+
+// reduce combines the numbers from left to right
+func reduce(numbers []float64, combine func(a, b float64) float64) float64 {
+	result := numbers[0]
+	for _, number := range numbers[1:] {
+		result = combine(result, number)
+	}
+	return result
+}
+
+// numberArgument returns an argument of a native that must be a number
+func numberArgument(function string, arguments []Value, index int) (float64, error) {
+	if number, ok := toNumber(arguments[index]); ok {
+		return number, nil
+	}
+	return 0, fmt.Errorf("Argument %d of '%s' must be a number, got %s.", index+1, function, typeName(arguments[index]))
+}
@@ -0,0 +1,46 @@
+package lox_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/codecrafters-io/interpreter-starter-go/lox"
+)
+
+func TestNonFiniteNumbersPrintLikeMathConstants(t *testing.T) {
+	var out bytes.Buffer
+	interpreter := lox.New(lox.Options{Stdout: &out, Stderr: io.Discard, Optimize: true})
+	_, err := interpreter.Run(context.Background(), `
+print math.inf;
+print -math.inf;
+print math.nan;
+print [math.inf, {"x": -math.inf}];
+print 1`+string(bytes.Repeat([]byte("0"), 308))+` * 10;
+`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "inf\n-inf\nnan\n[inf, {\"x\": -inf}]\ninf\n"; out.String() != want {
+		t.Errorf("printed %q, want %q", out.String(), want)
+	}
+}
+
+func TestMathRaisesDomainErrorsForNonFiniteResults(t *testing.T) {
+	tests := map[string]string{
+		"math.sqrt(-1);":   "Math domain error: sqrt(-1) is not a number.",
+		"math.log(0);":     "Math domain error: log(0) is not finite.",
+		"math.exp(1000);":  "Math domain error: exp(1000) is not finite.",
+		"math.pow(0, -1);": "Math domain error: pow(0, -1) is not finite.",
+	}
+	for source, want := range tests {
+		interpreter := lox.New(lox.Options{Stderr: io.Discard})
+		_, err := interpreter.Run(context.Background(), source)
+		var runtimeErr *lox.RuntimeError
+		if !errors.As(err, &runtimeErr) || runtimeErr.Message != want {
+			t.Errorf("%s: got %v, want %q", source, err, want)
+		}
+	}
+}
@@ -0,0 +1,188 @@
+// module.go
+package lox
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// Module is a Lox file loaded by an import statement. It runs once in its
+// own global environment; its top-level names are its properties.
+type Module struct {
+	Path    string // absolute path of the file
+	Globals *Environment
+}
+
+// Names returns the top-level names the module defines, sorted
+func (m *Module) Names() []string {
+	names := []string{}
+	for name := range m.Globals.defined {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (m *Module) Get(name string) (Value, error) {
+	value := m.Globals.Values[name]
+	if !m.Globals.defined[name] {
+		return nil, fmt.Errorf("Undefined property '%s'.", name)
+	}
+	if value == nil {
+		value = loxNil
+	}
+	return value, nil
+}
+
+func (m *Module) Set(name string, value Value) error {
+	return fmt.Errorf("Cannot assign to '%s' of module '%s'.", name, DisplayPath(m.Path))
+}
+
+func (m *Module) String() string {
+	return fmt.Sprintf("<module %s>", DisplayPath(m.Path))
+}
+
+// Modules loads the modules a program imports. A path is looked up relative
+// to the directory of the importing file first, then in each directory of
+// the search path; paths starting with ./ or ../ only relative to the
+// importing file. Every module is loaded once and shared by all importers.
+type Modules struct {
+	SearchPath  []string
+	interpreter *Interpreter
+	loaded      map[string]*Module
+	loading     []string // files being run, the importing file last
+}
+
+// newModules creates the module loader of an interpreter running the file
+// main, or source without a file when main is empty
+func newModules(interpreter *Interpreter, main string, searchPath []string) *Modules {
+	modules := &Modules{SearchPath: searchPath, interpreter: interpreter, loaded: map[string]*Module{}}
+	if main != "" {
+		if absolute, err := filepath.Abs(main); err == nil {
+			main = absolute
+		}
+		modules.loading = []string{main}
+	}
+	return modules
+}
+
+// Import returns the module at path, loading it on first use. It raises a
+// runtime error at line when the module cannot be found, has syntax errors
+// or imports itself through a cycle.
+func (m *Modules) Import(path string, line int, importer *Environment) *Module {
+	resolved, err := m.resolve(path)
+	if err != nil {
+		raiseError(line, err.Error())
+	}
+	for i, loading := range m.loading {
+		if loading == resolved {
+			chain := []string{}
+			for _, file := range m.loading[i:] {
+				chain = append(chain, DisplayPath(file))
+			}
+			chain = append(chain, DisplayPath(resolved))
+			raiseError(line, fmt.Sprintf("Import cycle: %s.", strings.Join(chain, " -> ")))
+		}
+	}
+	if module, ok := m.loaded[resolved]; ok {
+		return module
+	}
+
+	source, err := os.ReadFile(resolved)
+	if err != nil {
+		raiseError(line, fmt.Sprintf("Cannot read module '%s'.", path))
+	}
+	statements, err := parseSource(string(source), m.interpreter.options.Limits.Nesting)
+	if err != nil {
+		message := fmt.Sprintf("Syntax error in module '%s': %v", path, err)
+		panic(newRuntimeError(line, message, fmt.Sprintf("%s\n[line %d]\n", message, line), 65))
+	}
+	if m.interpreter.options.Optimize {
+		statements = Optimize(statements)
+	}
+
+	globals := m.interpreter.newGlobals()
+	globals.Context = importer.Context
+	globals.Hooks = importer.Hooks
+	globals.defined = map[string]bool{}
+	module := &Module{Path: resolved, Globals: globals}
+
+	m.loading = append(m.loading, resolved)
+	defer func() { m.loading = m.loading[:len(m.loading)-1] }()
+	for _, stmt := range statements {
+		ExecuteStatement(stmt, globals)
+	}
+	m.loaded[resolved] = module
+	return module
+}
+
+// Current returns the absolute path of the file being run: the innermost
+// module being loaded, else the main file. It is empty for source without a
+// file.
+func (m *Modules) Current() string {
+	if len(m.loading) == 0 {
+		return ""
+	}
+	return m.loading[len(m.loading)-1]
+}
+
+// resolve finds the file an import refers to
+func (m *Modules) resolve(path string) (string, error) {
+	dir := "."
+	if len(m.loading) > 0 {
+		dir = filepath.Dir(m.loading[len(m.loading)-1])
+	}
+
+	candidates := []string{}
+	switch {
+	case filepath.IsAbs(path):
+		candidates = append(candidates, path)
+	case strings.HasPrefix(path, "./") || strings.HasPrefix(path, "../"):
+		candidates = append(candidates, filepath.Join(dir, path))
+	default:
+		candidates = append(candidates, filepath.Join(dir, path))
+		for _, searchDir := range m.SearchPath {
+			candidates = append(candidates, filepath.Join(searchDir, path))
+		}
+	}
+
+	for _, candidate := range candidates {
+		if info, err := os.Stat(candidate); err == nil && !info.IsDir() {
+			return filepath.Abs(candidate)
+		}
+	}
+	return "", fmt.Errorf("Cannot find module '%s'.", path)
+}
+
+// parseSource scans and parses a module, returning its first error instead
+// of reporting it. Nesting deeper than maxDepth is a syntax error.
+func parseSource(source string, maxDepth int) ([]Stmt, error) {
+	scanner := NewLexer(source, false)
+	scanner.SetOutput(io.Discard, io.Discard)
+	scanner.Scan()
+	if errors := scanner.Errors(); len(errors) > 0 {
+		return nil, errors[0]
+	}
+	parser := NewParser(scanner, "run")
+	parser.SetMaxDepth(maxDepth)
+	statements, errors := parser.ParseWithErrors()
+	if len(errors) > 0 {
+		return nil, errors[0]
+	}
+	return statements, nil
+}
+
+// DisplayPath shows a file relative to the working directory when it is
+// inside it
+func DisplayPath(path string) string {
+	if wd, err := os.Getwd(); err == nil {
+		if relative, err := filepath.Rel(wd, path); err == nil && !strings.HasPrefix(relative, "..") {
+			return relative
+		}
+	}
+	return path
+}
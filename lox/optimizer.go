@@ -0,0 +1,235 @@
+// optimizer.go
+package lox
+
+import (
+	"math"
+	"strconv"
+)
+
+// Optimize folds constant expressions and simplifies the parsed statements.
+// Only expressions that are guaranteed to evaluate without a runtime error
+// are rewritten, so errors such as type mismatches still happen at runtime on
+// their original line.
+func Optimize(statements []Stmt) []Stmt {
+	for i, stmt := range statements {
+		statements[i] = optimizeStmt(stmt)
+	}
+	return statements
+}
+
+// optimizeStmt optimizes a single statement in place
+func optimizeStmt(stmt Stmt) Stmt {
+	switch s := stmt.(type) {
+	case *ExpressionStatement:
+		s.Expression = optimizeExpr(s.Expression)
+	case *PrintStatement:
+		s.Expression = optimizeExpr(s.Expression)
+	case *VarStmt:
+		if s.Initializer != nil {
+			s.Initializer = optimizeExpr(s.Initializer)
+		}
+	case *BlockStmt:
+		s.Statements = Optimize(s.Statements)
+	default:
+		return optimizeExpr(stmt)
+	}
+	return stmt
+}
+
+// optimizeExpr folds an expression bottom-up
+func optimizeExpr(expr Expr) Expr {
+	switch e := expr.(type) {
+	case *Grouping:
+		e.Expression = optimizeExpr(e.Expression)
+		if literal, ok := e.Expression.(*Literal); ok {
+			return literal
+		}
+	case *AssignStmt:
+		e.Value = optimizeExpr(e.Value)
+	case *Call:
+		e.Callee = optimizeExpr(e.Callee)
+		for i, argument := range e.Arguments {
+			e.Arguments[i] = optimizeExpr(argument)
+		}
+	case *Get:
+		e.Object = optimizeExpr(e.Object)
+	case *Set:
+		e.Object = optimizeExpr(e.Object)
+		e.Value = optimizeExpr(e.Value)
+	case *ListLiteral:
+		for i, element := range e.Elements {
+			e.Elements[i] = optimizeExpr(element)
+		}
+	case *MapLiteral:
+		for i := range e.Keys {
+			e.Keys[i] = optimizeExpr(e.Keys[i])
+			e.Values[i] = optimizeExpr(e.Values[i])
+		}
+	case *Index:
+		e.Object = optimizeExpr(e.Object)
+		e.Index = optimizeExpr(e.Index)
+	case *SetIndex:
+		e.Object = optimizeExpr(e.Object)
+		e.Index = optimizeExpr(e.Index)
+		e.Value = optimizeExpr(e.Value)
+	case *Unary:
+		e.Right = optimizeExpr(e.Right)
+		return optimizeUnary(e)
+	case *Binary:
+		e.Left = optimizeExpr(e.Left)
+		e.Right = optimizeExpr(e.Right)
+		return optimizeBinary(e)
+	}
+	return expr
+}
+
+// optimizeUnary folds ! and - on constants and removes !! pairs around
+// expressions that already produce a boolean
+func optimizeUnary(u *Unary) Expr {
+	if literal, ok := u.Right.(*Literal); ok {
+		value := literal.Eval(nil)
+		switch u.Operator.Type {
+		case "BANG":
+			return foldedLiteral(u.Eval(nil), u.Line)
+		case "MINUS":
+			if number, isNum := value.(float64); isNum && isFinite(number) {
+				return foldedLiteral(u.Eval(nil), u.Line)
+			}
+		}
+		return u
+	}
+
+	if inner, ok := u.Right.(*Unary); ok && u.Operator.Type == "BANG" && inner.Operator.Type == "BANG" {
+		if isBooleanExpr(inner.Right) {
+			return inner.Right
+		}
+	}
+	return u
+}
+
+// optimizeBinary folds operations on two constants and applies identities
+// such as x * 1 and x + 0 when x is known to be a number
+func optimizeBinary(b *Binary) Expr {
+	leftLit, leftIsLit := b.Left.(*Literal)
+	rightLit, rightIsLit := b.Right.(*Literal)
+
+	if leftIsLit && rightIsLit {
+		if canFoldBinary(b.Operator.Type, leftLit.Eval(nil), rightLit.Eval(nil)) {
+			// A number literal cannot hold an overflow to infinity
+			value := b.Eval(nil)
+			if number, isNum := value.(float64); !isNum || isFinite(number) {
+				return foldedLiteral(value, b.Line)
+			}
+		}
+		return b
+	}
+
+	switch b.Operator.Type {
+	case "STAR":
+		if isNumberLiteral(rightLit, 1) && isNumericExpr(b.Left) {
+			return b.Left
+		}
+		if isNumberLiteral(leftLit, 1) && isNumericExpr(b.Right) {
+			return b.Right
+		}
+	case "PLUS":
+		if isNumberLiteral(rightLit, 0) && isNumericExpr(b.Left) {
+			return b.Left
+		}
+		if isNumberLiteral(leftLit, 0) && isNumericExpr(b.Right) {
+			return b.Right
+		}
+	case "MINUS":
+		if isNumberLiteral(rightLit, 0) && isNumericExpr(b.Left) {
+			return b.Left
+		}
+	case "SLASH":
+		if isNumberLiteral(rightLit, 1) && isNumericExpr(b.Left) {
+			return b.Left
+		}
+	}
+	return b
+}
+
+// canFoldBinary reports whether Binary.Eval would succeed on these operands
+func canFoldBinary(operator string, left, right interface{}) bool {
+	_, leftIsNum := toNumber(left)
+	rightNum, rightIsNum := toNumber(right)
+
+	switch operator {
+	case "PLUS":
+		_, leftIsStr := left.(string)
+		_, rightIsStr := right.(string)
+		return (leftIsNum && rightIsNum) || (leftIsStr && rightIsStr)
+	case "SLASH":
+		return leftIsNum && rightIsNum && rightNum != 0
+	case "MINUS", "STAR", "GREATER", "GREATER_EQUAL", "LESS", "LESS_EQUAL":
+		return leftIsNum && rightIsNum
+	case "EQUAL_EQUAL", "BANG_EQUAL":
+		return true
+	}
+	return false
+}
+
+// foldedLiteral turns an evaluated value back into a literal node
+func foldedLiteral(value interface{}, line int) *Literal {
+	switch v := value.(type) {
+	case float64:
+		return &Literal{Value: formatAsFloat(strconv.FormatFloat(v, 'f', -1, 64)), Type: "number", Line: line}
+	case bool:
+		return &Literal{Value: v, Type: "boolean", Line: line}
+	case string:
+		return &Literal{Value: v, Type: "string", Line: line}
+	}
+	return &Literal{Value: nil, Type: "nil", Line: line}
+}
+
+func isFinite(number float64) bool {
+	return !math.IsInf(number, 0) && !math.IsNaN(number)
+}
+
+// isNumberLiteral checks if a literal is the given number
+func isNumberLiteral(l *Literal, want float64) bool {
+	if l == nil || l.Type != "number" {
+		return false
+	}
+	num, ok := l.Eval(nil).(float64)
+	return ok && num == want
+}
+
+// isNumericExpr reports whether an expression can only produce a number
+// (or fail with its own runtime error before producing anything else)
+func isNumericExpr(expr Expr) bool {
+	switch e := expr.(type) {
+	case *Literal:
+		return e.Type == "number"
+	case *Grouping:
+		return isNumericExpr(e.Expression)
+	case *Unary:
+		return e.Operator.Type == "MINUS"
+	case *Binary:
+		switch e.Operator.Type {
+		case "MINUS", "STAR", "SLASH":
+			return true
+		}
+	}
+	return false
+}
+
+// isBooleanExpr reports whether an expression always produces a boolean
+func isBooleanExpr(expr Expr) bool {
+	switch e := expr.(type) {
+	case *Literal:
+		return e.Type == "boolean"
+	case *Grouping:
+		return isBooleanExpr(e.Expression)
+	case *Unary:
+		return e.Operator.Type == "BANG"
+	case *Binary:
+		switch e.Operator.Type {
+		case "EQUAL_EQUAL", "BANG_EQUAL", "GREATER", "GREATER_EQUAL", "LESS", "LESS_EQUAL":
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,521 @@
+package lox
+
+import (
+	"fmt"
+	"strings"
+)
+
+type Parser struct {
+	lexer *Lexer
+	pos   int
+	mode  string
+	depth    int // expressions and blocks being parsed inside each other
+	maxDepth int
+}
+
+// ParseError is a syntax error together with the token it was found at
+type ParseError struct {
+	Line    int
+	Column  int
+	Lexeme  string
+	Message string
+	report  string // text written to stderr by Parse
+}
+
+func (e ParseError) Error() string {
+	return strings.TrimSuffix(e.report, "\n")
+}
+
+// NewParser initializes a new parser with the lexer input
+func NewParser(lexer *Lexer, mode string) *Parser {
+	return &Parser{
+		lexer: lexer,
+		pos:   0,
+		mode:  mode,
+		maxDepth: MaxNesting,
+	}
+}
+
+// SetMaxDepth lowers how deeply expressions and blocks may nest before the
+// parser reports a syntax error, MaxNesting by default
+func (p *Parser) SetMaxDepth(limit int) {
+	p.maxDepth = Limits{Nesting: limit}.nesting()
+}
+
+// nest counts a level of nesting until the returned function is called.
+// Nesting beyond the limit is a syntax error, so deeply nested source
+// cannot overflow the stack.
+func (p *Parser) nest() func() {
+	p.depth++
+	if p.depth > p.maxDepth {
+		p.error(fmt.Sprintf("Nesting limit of %d exceeded.", p.maxDepth))
+	}
+	return func() { p.depth-- }
+}
+
+// Parse starts parsing and returns the resulting AST. It stops at the first
+// syntax error, which is written to the lexer's stderr and returned.
+func (p *Parser) Parse() ([]Stmt, error) {
+	statements := []Stmt{}
+
+	for !p.isAtEnd() {
+		stmt, err := p.tryStatement()
+		if err != nil {
+			fmt.Fprint(p.lexer.stderr, err.report)
+			return statements, *err
+		}
+		statements = append(statements, stmt)
+	}
+
+	return statements, nil
+}
+
+// ParseWithErrors parses the whole program without reporting syntax errors.
+// After an error the parser skips to the next statement and carries on, so
+// every error in the source is returned.
+func (p *Parser) ParseWithErrors() ([]Stmt, []ParseError) {
+	statements := []Stmt{}
+	errors := []ParseError{}
+
+	for !p.isAtEnd() {
+		start := p.pos
+		stmt, err := p.tryStatement()
+		if err != nil {
+			errors = append(errors, *err)
+			if p.pos == start {
+				p.pos++
+			}
+			p.synchronize()
+			continue
+		}
+		statements = append(statements, stmt)
+	}
+
+	return statements, errors
+}
+
+// ParseExpression parses source holding a single expression, as typed in
+// the debugger, returning a syntax error
+func (p *Parser) ParseExpression() (expr Expr, parseErr *ParseError) {
+	defer func() {
+		if r := recover(); r != nil {
+			err, ok := r.(ParseError)
+			if !ok {
+				panic(r)
+			}
+			parseErr = &err
+		}
+	}()
+
+	expr = p.parseAssignment()
+	if !p.isAtEnd() {
+		p.error("Expect end of expression.")
+	}
+	return expr, nil
+}
+
+// tryStatement parses one statement, turning a syntax error into a value
+func (p *Parser) tryStatement() (stmt Stmt, parseErr *ParseError) {
+	p.depth = 0
+	defer func() {
+		if r := recover(); r != nil {
+			err, ok := r.(ParseError)
+			if !ok {
+				panic(r)
+			}
+			parseErr = &err
+		}
+	}()
+	return p.parseStatement(), nil
+}
+
+// synchronize skips tokens until the start of the next statement
+func (p *Parser) synchronize() {
+	for !p.isAtEnd() {
+		if p.previous().Type == "SEMICOLON" {
+			return
+		}
+		switch p.peek().Type {
+		case "VAR", "PRINT", "IMPORT", "LEFT_BRACE", "RIGHT_BRACE":
+			return
+		}
+		p.pos++
+	}
+}
+
+// parseStatement handles either print statements or expression statements
+func (p *Parser) parseStatement() Stmt {
+	if p.match("PRINT") {
+		return p.printStatement()
+	} else if p.match("VAR") {
+		return p.varDeclaration()
+	} else if p.check("IDENTIFIER") && p.checkNext("EQUAL") { // A bare `x;` reads x like any expression
+		p.match("IDENTIFIER")
+		return p.varAssignment()
+	} else if p.match("LEFT_BRACE") {
+		return p.blockStatement()
+	} else if p.match("IMPORT") {
+		return p.importStatement()
+	}
+	return p.expressionStatement()
+}
+
+// importStatement parses `import "path";` or `import name from "path";`.
+// "from" is only a keyword here, so it stays usable as a variable name.
+func (p *Parser) importStatement() Stmt {
+	line := p.previous().Line
+	name := ""
+	if p.match("IDENTIFIER") {
+		name = p.previous().Lexeme
+		if !p.check("IDENTIFIER") || p.peek().Lexeme != "from" {
+			p.error("Expect 'from' after module name.")
+		}
+		p.match("IDENTIFIER")
+	}
+	p.consume("STRING", "Expect module path.")
+	path := p.previous().Literal
+	p.consume("SEMICOLON", "Expect ';' after import.")
+	return &ImportStmt{Path: path, Name: name, Line: line}
+}
+
+
+// blockStatement parses a block of statements enclosed in braces {}
+func (p *Parser) blockStatement() Stmt {
+	defer p.nest()()
+	line := p.previous().Line
+	statements := []Stmt{}
+
+	// Loop to parse statements until a closing brace '}' is encountered
+	for !p.isAtEnd() && !p.check("RIGHT_BRACE") {
+		statements = append(statements, p.parseStatement())
+	}
+
+	// Ensure there's a closing brace for the block
+	p.consume("RIGHT_BRACE", "Expect '}' after block.")
+
+	return &BlockStmt{Statements: statements, Line: line, EndLine: p.previous().Line}
+}
+
+
+
+// varAssignment parses a variable assigment
+func (p *Parser) varAssignment() Stmt {
+	identifier := p.previous()
+
+	var initializer Expr
+	if p.match("EQUAL") {
+		initializer = p.parseAssignment()
+		
+	}
+
+	// Ensure there's a semicolon after the variable declaration
+	p.consume("SEMICOLON", "Expect ';' after variable declaration.")
+	return &VarStmt{Name: identifier.Lexeme, Initializer: initializer, VarUsed: false, Line: p.previous().Line,
+		NameLine: identifier.Line, NameColumn: identifier.Column}
+}
+
+// varDeclaration parses a variable declaration
+func (p *Parser) varDeclaration() Stmt {
+	// Expect an identifier after 'var'
+	p.consume("IDENTIFIER", "Expect variable name.")
+	identifier := p.previous()
+	var initializer Expr
+	if p.match("EQUAL") { // If '=' follows, there should be an initializer expression
+		initializer = p.parseAssignment()
+	}
+
+	// Ensure there's a semicolon after the variable declaration
+	p.consume("SEMICOLON", "Expect ';' after variable declaration.")
+	return &VarStmt{Name: identifier.Lexeme, Initializer: initializer, VarUsed: true, Line: p.previous().Line,
+		NameLine: identifier.Line, NameColumn: identifier.Column}
+}
+
+// printStatement parses a print statement
+func (p *Parser) printStatement() Stmt {
+	line := p.previous().Line
+	expr := p.parseAssignment() // Parse the expression after "print"
+	if p.mode == "run" {
+		if !p.checkSemicolon() {
+			p.fail(p.errorAt(p.previous(), "Expect ';' after expression",
+				fmt.Sprintf("[line %d]: Expect ';' after expression\n", p.previous().Line)))
+		}
+		p.consume("SEMICOLON", "Expect ';' after expression.")
+	}
+	return &PrintStatement{Expression: expr, Line: line} // Return a PrintStatement node
+}
+
+// expressionStatement parses an expression statement
+func (p *Parser) expressionStatement() Stmt {
+	line := p.peek().Line
+	expr := p.parseAssignment() // Parse the expression
+	if p.mode == "run" {
+		if !p.checkSemicolon() {
+			p.fail(p.errorAt(p.previous(), "Expect ';' after expression",
+				fmt.Sprintf("[line %d]: Expect ';' after expression", p.previous().Line)))
+		}
+		p.consume("SEMICOLON", "Expect ';' after expression.")
+	}
+	return &ExpressionStatement{Expression: expr, Line: line} // Return an expression statement
+}
+
+func(p *Parser) parseAssignment() Stmt {
+	expr := p.parseEquality()
+
+	for p.match("EQUAL") {
+		equals := p.previous()
+		leave := p.nest() // left nested after a syntax error until the next statement
+		value := p.parseAssignment()
+		leave()
+
+		if identifier, ok := expr.(*Identifier); ok {
+			return &AssignStmt{Name: identifier.Name, Value: value, Line: equals.Line,
+				NameLine: identifier.Line, NameColumn: identifier.Column}
+		}
+		if get, ok := expr.(*Get); ok {
+			return &Set{Object: get.Object, Name: get.Name, Value: value, Line: equals.Line}
+		}
+		if index, ok := expr.(*Index); ok {
+			return &SetIndex{Object: index.Object, Index: index.Index, Value: value, Line: equals.Line}
+		}
+		// p.error("Invalid Assignment ")
+
+	}
+	return expr
+}
+
+func(p *Parser) parseEquality() Stmt {
+	expr := p.parseComparison()
+
+	for p.match("EQUAL_EQUAL", "BANG_EQUAL") {
+		operator := p.previous()
+		right := p.parseComparison()
+		expr = &Binary{Left: expr, Operator: operator, Right: right, Line: operator.Line}
+	}
+
+	return expr
+}
+
+// parseComparison handles >, <, >=, <= operators
+func (p *Parser) parseComparison() Expr {
+	expr := p.parseAdditionSubstraction() // Start by parsing addition and subtraction
+
+	for p.match("GREATER", "GREATER_EQUAL", "LESS", "LESS_EQUAL") { // Look for comparison operators
+		operator := p.previous()
+		right := p.parseAdditionSubstraction() // Parse the right-hand operand
+		expr = &Binary{Left: expr, Operator: operator, Right: right, Line: operator.Line}
+	}
+
+	return expr
+}
+
+// parseAdditionSubstraction handles + and - operators
+func (p *Parser) parseAdditionSubstraction() Expr {
+	expr := p.parseMultiplication()
+
+	for p.match("PLUS", "MINUS") {
+		operator := p.previous()
+		right := p.parseMultiplication()
+		expr = &Binary{Left: expr, Operator: operator, Right: right, Line: operator.Line}
+	}
+
+	return expr
+}
+
+// parseMultiplication handles * and / operators with their precedence
+func (p *Parser) parseMultiplication() Expr {
+	expr := p.parseUnary() // Start by parsing unary operators
+
+	for p.match("STAR", "SLASH") { // Look for * or / operators
+		operator := p.previous()
+		right := p.parseUnary() // Parse the right-hand operand (which could be a unary expression)
+		expr = &Binary{Left: expr, Operator: operator, Right: right, Line: operator.Line}
+	}
+
+	return expr
+}
+
+// parseUnary handles unary operators (e.g., -23, !true) or forwards to primary expressions
+func (p *Parser) parseUnary() Expr {
+	defer p.nest()()
+	if p.match("BANG", "MINUS") { // Check for the unary operators
+		operator := p.previous()
+		right := p.parseUnary() // Recursively parse the right-hand operand
+		return &Unary{Operator: operator, Right: right, Line: operator.Line}
+	}
+
+	// If it's not a unary expression, parse a call or a primary expression
+	return p.parseCall()
+}
+
+// parseCall handles calls, property accesses and indexes following a primary
+// expression, such as f(1, 2) or point.x
+func (p *Parser) parseCall() Expr {
+	expr := p.parsePrimary()
+
+	for {
+		if p.match("LEFT_PAREN") {
+			arguments := []Expr{}
+			if !p.check("RIGHT_PAREN") {
+				arguments = append(arguments, p.parseAssignment())
+				for p.match("COMMA") {
+					arguments = append(arguments, p.parseAssignment())
+				}
+			}
+			p.consume("RIGHT_PAREN", "Expect ')' after arguments.")
+			expr = &Call{Callee: expr, Arguments: arguments, Line: p.previous().Line}
+		} else if p.match("DOT") {
+			p.consume("IDENTIFIER", "Expect property name after '.'.")
+			expr = &Get{Object: expr, Name: p.previous().Lexeme, Line: p.previous().Line}
+		} else if p.match("LEFT_BRACKET") {
+			index := p.parseAssignment()
+			p.consume("RIGHT_BRACKET", "Expect ']' after index.")
+			expr = &Index{Object: expr, Index: index, Line: p.previous().Line}
+		} else {
+			return expr
+		}
+	}
+}
+
+// parsePrimary handles numbers, strings, booleans, lists, maps, and
+// parentheses
+func (p *Parser) parsePrimary() Expr {
+	switch {
+	case p.match("TRUE"):
+		return &Literal{Value: true, Type: "boolean", Line: p.previous().Line}
+	case p.match("FALSE"):
+		return &Literal{Value: false, Type: "boolean", Line: p.previous().Line}
+	case p.match("NIL"):
+		return &Literal{Value: nil, Type: "nil", Line: p.previous().Line}
+	case p.match("NUMBER"):
+		return &Literal{Value: p.previous().Literal, Type: "number", Line: p.previous().Line, Lexeme: p.previous().Lexeme}
+	case p.match("STRING"):
+		return &Literal{Value: p.previous().Literal, Type: "string", Line: p.previous().Line}
+	case p.match("LEFT_BRACKET"):
+		elements := []Expr{}
+		if !p.check("RIGHT_BRACKET") {
+			elements = append(elements, p.parseAssignment())
+			for p.match("COMMA") {
+				elements = append(elements, p.parseAssignment())
+			}
+		}
+		p.consume("RIGHT_BRACKET", "Expect ']' after list elements.")
+		return &ListLiteral{Elements: elements, Line: p.previous().Line}
+	case p.match("LEFT_BRACE"):
+		// Statements starting with a brace are blocks, so here it is a map
+		literal := &MapLiteral{Keys: []Expr{}, Values: []Expr{}}
+		if !p.check("RIGHT_BRACE") {
+			for {
+				literal.Keys = append(literal.Keys, p.parseAssignment())
+				p.consume("COLON", "Expect ':' after map key.")
+				literal.Values = append(literal.Values, p.parseAssignment())
+				if !p.match("COMMA") {
+					break
+				}
+			}
+		}
+		p.consume("RIGHT_BRACE", "Expect '}' after map entries.")
+		literal.Line = p.previous().Line
+		return literal
+	case p.match("IDENTIFIER"):
+		return &Identifier{Name: p.previous().Lexeme, Line: p.previous().Line, Column: p.previous().Column}
+	case p.match("LEFT_PAREN"):
+		line := p.previous().Line
+		expr := p.parseEquality() // Recursively parse the inner expression inside parentheses
+		p.consume("RIGHT_PAREN", "Expect ')' after expression.")
+		return &Grouping{Expression: expr, Line: line} // Directly return the expression, not a group node
+	default:
+		p.error("Expected expression.")
+		return nil
+	}
+}
+
+// match checks if the current token matches one of the expected types
+func (p *Parser) match(types ...string) bool {
+	if p.isAtEnd() {
+		return false
+	}
+	for _, t := range types {
+		if p.lexer.tokens[p.pos].Type == t {
+			p.pos++
+			return true
+		}
+	}
+	return false
+}
+
+// previous returns the last matched token
+func (p *Parser) previous() Token {
+	return p.lexer.tokens[p.pos-1]
+}
+
+// consume checks for a specific token and advances, or throws an error if it doesn't match
+func (p *Parser) consume(expectedType, errorMessage string) {
+	if !p.match(expectedType) {
+		p.error(errorMessage)
+	}
+}
+
+// isAtEnd checks if the parser has reached the end of the token list
+func (p *Parser) isAtEnd() bool {
+	return p.pos >= len(p.lexer.tokens)
+}
+
+// checkSemicolon checks if the current token is a semicolon without advancing the position
+func (p *Parser) checkSemicolon() bool {
+	if p.isAtEnd() {
+		return false
+	}
+	return p.lexer.tokens[p.pos].Type == "SEMICOLON"
+}
+
+// peek returns the current token without consuming it
+func (p *Parser) peek() Token {
+	if p.isAtEnd() {
+		return Token{Type: "EOF", Line: p.lexer.line}
+	}
+	return p.lexer.tokens[p.pos]
+}
+
+// checkNext checks the token after the current one, which is missing at
+// the end of the program
+func (p *Parser) checkNext(tokenTypes ...string) bool {
+	if p.pos+1 >= len(p.lexer.tokens) {
+		return true
+	}
+	for _, tokenType := range tokenTypes {
+		if p.lexer.tokens[p.pos+1].Type == tokenType {
+			return true
+		}
+	}
+	return false
+}
+
+// check checks if the current token is of the expected type without consuming it
+func (p *Parser) check(tokenType string) bool {
+	if p.isAtEnd() {
+		return false
+	}
+	return p.lexer.tokens[p.pos].Type == tokenType
+}
+
+
+func (p *Parser) error(msg string) {
+	if p.pos < len(p.lexer.tokens) {
+		token := p.lexer.tokens[p.pos]
+		p.fail(p.errorAt(token, msg, fmt.Sprintf("[line %d] Error at '%s': %s\n", token.Line, token.Lexeme, msg)))
+	} else {
+		// Handle the case where the token list is exhausted
+		eof := p.lexer.EOF()
+		p.fail(p.errorAt(eof, msg, fmt.Sprintf("[line %d] Error at end: %s\n", p.lexer.line, msg)))
+	}
+}
+
+// errorAt builds a ParseError for a token
+func (p *Parser) errorAt(token Token, msg, report string) ParseError {
+	return ParseError{Line: token.Line, Column: token.Column, Lexeme: token.Lexeme, Message: msg, report: report}
+}
+
+// fail stops parsing with a syntax error, which the Parse methods recover
+func (p *Parser) fail(err ParseError) {
+	panic(err)
+}
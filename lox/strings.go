@@ -0,0 +1,133 @@
+// strings.go
+package lox
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// stringProperty returns a property of a string value: its length or one of
+// its methods. Positions and lengths count characters (runes), not bytes.
+// Strings returned by the methods are counted against the budget, if any,
+// and reported at line.
+func stringProperty(s string, name string, budget *Budget, line int) (Value, error) {
+	method := func(params int, function func(arguments []Value) (Value, error)) *NativeFunction {
+		return &NativeFunction{Name: name, Params: params, Function: func(arguments []Value) (Value, error) {
+			value, err := function(arguments)
+			if result, ok := value.(string); ok && budget != nil {
+				budget.allocateString(line, len(result))
+			}
+			return value, err
+		}}
+	}
+
+	switch name {
+	case "length":
+		return float64(utf8.RuneCountInString(s)), nil
+	case "upper":
+		return method(0, func([]Value) (Value, error) {
+			return strings.ToUpper(s), nil
+		}), nil
+	case "lower":
+		return method(0, func([]Value) (Value, error) {
+			return strings.ToLower(s), nil
+		}), nil
+	case "trim":
+		return method(0, func([]Value) (Value, error) {
+			return strings.TrimSpace(s), nil
+		}), nil
+	case "split":
+		// An empty separator splits the string into its characters
+		return method(1, func(arguments []Value) (Value, error) {
+			separator, err := stringArgument(name, arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			elements := []Value{}
+			for _, part := range strings.Split(s, separator) {
+				elements = append(elements, part)
+			}
+			return budget.newList(elements)
+		}), nil
+	case "indexOf":
+		// The position of the first occurrence, or -1
+		return method(1, func(arguments []Value) (Value, error) {
+			substring, err := stringArgument(name, arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			index := strings.Index(s, substring)
+			if index < 0 {
+				return float64(-1), nil
+			}
+			return float64(utf8.RuneCountInString(s[:index])), nil
+		}), nil
+	case "substring":
+		// The characters from start up to but not including end, which
+		// defaults to the length
+		return method(-1, func(arguments []Value) (Value, error) {
+			if len(arguments) < 1 || len(arguments) > 2 {
+				return nil, fmt.Errorf("Expected 1 or 2 arguments but got %d.", len(arguments))
+			}
+			runes := []rune(s)
+			bounds := []int{0, len(runes)}
+			for index := range arguments {
+				bound, err := integerArgument(name, arguments, index)
+				if err != nil {
+					return nil, err
+				}
+				bounds[index] = bound
+			}
+			start, end := bounds[0], bounds[1]
+			if start < 0 || end > len(runes) || start > end {
+				return nil, fmt.Errorf("Substring %d to %d is out of range for length %d.", start, end, len(runes))
+			}
+			return string(runes[start:end]), nil
+		}), nil
+	case "replace":
+		// Every occurrence is replaced
+		return method(2, func(arguments []Value) (Value, error) {
+			old, err := stringArgument(name, arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			replacement, err := stringArgument(name, arguments, 1)
+			if err != nil {
+				return nil, err
+			}
+			return strings.ReplaceAll(s, old, replacement), nil
+		}), nil
+	case "startsWith":
+		return method(1, func(arguments []Value) (Value, error) {
+			prefix, err := stringArgument(name, arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			return strings.HasPrefix(s, prefix), nil
+		}), nil
+	case "endsWith":
+		return method(1, func(arguments []Value) (Value, error) {
+			suffix, err := stringArgument(name, arguments, 0)
+			if err != nil {
+				return nil, err
+			}
+			return strings.HasSuffix(s, suffix), nil
+		}), nil
+	}
+	return nil, fmt.Errorf("Undefined property '%s'.", name)
+}
+
+// integerArgument returns an argument of a native that must be a whole
+// number
+func integerArgument(function string, arguments []Value, index int) (int, error) {
+	number, err := numberArgument(function, arguments, index)
+	if err != nil {
+		return 0, err
+	}
+	if number != math.Trunc(number) || math.Abs(number) > math.MaxInt32 {
+		return 0, fmt.Errorf("Argument %d of '%s' must be an integer, got %s.", index+1, function, Stringify(number))
+	}
+	return int(number), nil
+}
@@ -1,16 +1,19 @@
-package main
+package lox
 
 const (
 	LEFT_PAREN  = "("
 	RIGHT_PAREN = ")"
 	LEFT_BRACE  = "{"
 	RIGHT_BRACE = "}"
+	LEFT_BRACKET  = "["
+	RIGHT_BRACKET = "]"
 	STAR        = "*"
 	DOT         = "."
 	COMMA       = ","
 	PLUS        = "+"
 	MINUS       = "-"
 	SEMICOLON   = ";"
+	COLON       = ":"
 	EQUAL       = "="
 	BANG        = "!"
 	LT          = "<"
@@ -30,6 +33,7 @@ var RESERVED_WORDS = map[string]string {
     "for": "FOR",
 	"fun": "FUN",
     "if": "IF",
+	"import": "IMPORT",
     "nil": "NIL",
     "or": "OR",
 	"print": "PRINT",
@@ -0,0 +1,137 @@
+// value.go
+package lox
+
+import (
+	"context"
+	"fmt"
+	"math"
+)
+
+// Callable is a value that can be called from Lox, such as a Go function
+// bound by the host. Arity is the number of arguments expected, or -1 when
+// any number is accepted. An error returned by Call becomes a runtime error
+// at the line of the call.
+type Callable interface {
+	Arity() int
+	Call(arguments []Value) (Value, error)
+}
+
+// ContextCallable is a Callable that is given the context of the program
+// calling it, so that blocking work can stop when the program is cancelled
+// or times out. Call is used where there is no context.
+type ContextCallable interface {
+	Callable
+	CallContext(ctx context.Context, arguments []Value) (Value, error)
+}
+
+// Object is a value with properties, read with `object.name` and written
+// with `object.name = value`. Methods are properties holding a Callable.
+type Object interface {
+	Get(name string) (Value, error)
+	Set(name string, value Value) error
+}
+
+// indexable is a collection read with xs[i] and written with xs[i] = v
+type indexable interface {
+	At(index Value) (Value, error)
+	SetAt(index Value, value Value) error
+}
+
+// NativeFunction is a Callable implemented in Go with Lox values as
+// arguments, for natives that do not need reflection
+type NativeFunction struct {
+	Name     string
+	Params   int // -1 for any number of arguments
+	Function func(arguments []Value) (Value, error)
+}
+
+func (f *NativeFunction) Arity() int {
+	return f.Params
+}
+
+func (f *NativeFunction) Call(arguments []Value) (Value, error) {
+	return f.Function(arguments)
+}
+
+func (f *NativeFunction) String() string {
+	return "<native fn>"
+}
+
+// Namespace is a read-only object grouping natives and constants, such as
+// math
+type Namespace struct {
+	Name    string
+	Members map[string]Value
+}
+
+func (n *Namespace) Get(name string) (Value, error) {
+	if value, ok := n.Members[name]; ok {
+		return value, nil
+	}
+	return nil, fmt.Errorf("Undefined property '%s'.", name)
+}
+
+func (n *Namespace) Set(name string, value Value) error {
+	return fmt.Errorf("Cannot assign to '%s' of namespace '%s'.", name, n.Name)
+}
+
+func (n *Namespace) String() string {
+	return fmt.Sprintf("<namespace %s>", n.Name)
+}
+
+// nilValue is the type of the Lox nil value inside the interpreter. It has a
+// type of its own so that nil is never mistaken for the string "nil"; hosts
+// see Go nil instead.
+type nilValue struct{}
+
+func (nilValue) String() string {
+	return "nil"
+}
+
+// loxNil is the Lox nil value
+var loxNil Value = nilValue{}
+
+// Stringify formats a value the way print shows it. A variable declared
+// without an initializer holds Go nil, which is Lox nil as well. Infinities
+// and NaN are spelled inf, -inf and nan, like the math constants.
+func Stringify(value Value) string {
+	switch v := value.(type) {
+	case nil:
+		return "nil"
+	case float64:
+		switch {
+		case math.IsInf(v, 1):
+			return "inf"
+		case math.IsInf(v, -1):
+			return "-inf"
+		case math.IsNaN(v):
+			return "nan"
+		}
+	}
+	return fmt.Sprint(value)
+}
+
+// typeName describes the type of a value in error messages
+func typeName(value Value) string {
+	switch v := value.(type) {
+	case nil, nilValue:
+		return "nil"
+	case float64, int:
+		return "number"
+	case string:
+		return "string"
+	case bool:
+		return "boolean"
+	case *List:
+		return "list"
+	case *Map:
+		return "map"
+	case *GoValue:
+		return v.value.Type().String()
+	case Callable:
+		return "function"
+	case Object:
+		return "object"
+	}
+	return fmt.Sprintf("%T", value)
+}